@@ -0,0 +1,176 @@
+package checkgitci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Maximum number of bytes of an error response body that are read, and
+// the maximum number kept on an APIError.
+const (
+	apiErrorReadLimit = 64 << 10
+	apiErrorBodyLimit = 512
+)
+
+// Query parameters removed from URLs on an APIError, because they
+// may hold credentials.
+var sensitiveQueryParams = []string{"access_token", "client_id", "client_secret", "token"}
+
+// APIError is returned when the GitHub API responds with an unexpected
+// status code. It satisfies errors.Is(err, ErrorFailedAPICall), so code
+// written against ErrorFailedAPICall keeps working.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// URL is the requested url, with any credentials removed.
+	URL string
+
+	// Message and DocumentationURL are taken from GitHub's JSON
+	// error response, when there is one.
+	Message          string
+	DocumentationURL string
+
+	// RequestID is the X-GitHub-Request-Id header, which GitHub
+	// support can use to find the request.
+	RequestID string
+
+	// Body is the start of the response body.
+	Body string
+
+	// RateLimit is the rate limit information from the response.
+	RateLimit RateLimit
+
+	// rateLimited is true if the response says the rate limit was hit.
+	rateLimited bool
+}
+
+// apiErrorResponse holds selected information from a GitHub API error.
+type apiErrorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+}
+
+// newAPIError builds an APIError from a response with an unexpected status.
+func newAPIError(resp *http.Response, requestURL string) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		URL:        sanitizeURL(requestURL),
+		RequestID:  resp.Header.Get("X-GitHub-Request-Id"),
+	}
+
+	// Read the start of the body, and try to decode GitHub's error
+	// message from it. Errors here are ignored, since the status code
+	// is what matters.
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, apiErrorReadLimit))
+	var body apiErrorResponse
+	if json.Unmarshal(bodyBytes, &body) == nil {
+		e.Message = body.Message
+		e.DocumentationURL = body.DocumentationURL
+	}
+	if len(bodyBytes) > apiErrorBodyLimit {
+		bodyBytes = bodyBytes[:apiErrorBodyLimit]
+	}
+	e.Body = string(bodyBytes)
+
+	// GitHub signals rate limits with 429, or with 403 and either no
+	// remaining requests or a rate limit message.
+	e.rateLimited = resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden &&
+			(resp.Header.Get("X-RateLimit-Remaining") == "0" ||
+				strings.Contains(strings.ToLower(e.Message), "rate limit")))
+	return e
+}
+
+// Error returns the error message.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: %d %s from %s", ErrorFailedAPICall.Error(), e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request ID " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is ErrorFailedAPICall.
+func (e *APIError) Is(target error) bool {
+	return target == ErrorFailedAPICall
+}
+
+// IsNotFound reports whether err is an APIError for a 404 Not Found
+// response, such as for a repository that does not exist or that the
+// token cannot see.
+func IsNotFound(err error) bool {
+	var e *APIError
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is an APIError for a 401
+// Unauthorized response, such as for a bad or expired token.
+func IsUnauthorized(err error) bool {
+	var e *APIError
+	return errors.As(err, &e) && e.StatusCode == http.StatusUnauthorized
+}
+
+// IsRateLimited reports whether err is an APIError for a response that
+// was rejected because of a primary or secondary rate limit.
+func IsRateLimited(err error) bool {
+	var e *APIError
+	return errors.As(err, &e) && e.rateLimited
+}
+
+// DecodeError is returned when a GitHub API response body cannot be
+// decoded, such as an HTML error page or a truncated body. It satisfies
+// errors.Is(err, ErrorDecodeResponse), and unwraps to the decoding error.
+type DecodeError struct {
+	// URL is the requested url, with any credentials removed.
+	URL string
+
+	// ContentType is the Content-Type header of the response.
+	ContentType string
+
+	// Err is the underlying decoding error.
+	Err error
+}
+
+// Error returns the error message.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: %s (content type %q): %v", ErrorDecodeResponse.Error(), e.URL, e.ContentType, e.Err)
+}
+
+// Is reports whether target is ErrorDecodeResponse.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrorDecodeResponse
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// sanitizeURL removes user information and credential query parameters
+// from a url, so it is safe to include in errors.
+func sanitizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid url>"
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		query := u.Query()
+		for _, key := range sensitiveQueryParams {
+			if query.Has(key) {
+				query.Set(key, "REDACTED")
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,121 @@
+package checkgitci
+
+import (
+	"context"
+	"os"
+)
+
+// Environment variables checked (in order) by EnvTokenSource.
+var tokenEnvVars = []string{"GITHUB_TOKEN", "GH_TOKEN"}
+
+// TokenSource supplies the token used to authenticate GitHub API requests.
+// Token is called before every request, so implementations that refresh
+// tokens should cache them between calls. Implementations must be safe
+// for concurrent use.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// TokenSourceError is returned when a TokenSource fails to supply a token.
+// It satisfies errors.Is(err, ErrorTokenSource).
+type TokenSourceError struct {
+	Err error
+}
+
+// Error returns the error message.
+func (e *TokenSourceError) Error() string {
+	return ErrorTokenSource.Error() + ": " + e.Err.Error()
+}
+
+// Is reports whether target is ErrorTokenSource.
+func (e *TokenSourceError) Is(target error) bool {
+	return target == ErrorTokenSource
+}
+
+// Unwrap returns the error from the TokenSource.
+func (e *TokenSourceError) Unwrap() error {
+	return e.Err
+}
+
+// staticTokenSource is a TokenSource that always returns the same token.
+type staticTokenSource struct {
+	token string
+}
+
+// Token returns the static token.
+func (s staticTokenSource) Token() (string, error) {
+	return s.token, nil
+}
+
+// StaticTokenSource returns a TokenSource that always returns token, such
+// as a personal access token.
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource{token: token}
+}
+
+// envTokenSource is a TokenSource that reads the token from the environment.
+type envTokenSource struct{}
+
+// Token returns the first non-empty token from the GITHUB_TOKEN or GH_TOKEN
+// environment variables, or ErrorNoToken if neither is set.
+func (envTokenSource) Token() (string, error) {
+	for _, key := range tokenEnvVars {
+		if token := os.Getenv(key); token != "" {
+			return token, nil
+		}
+	}
+	return "", ErrorNoToken
+}
+
+// EnvTokenSource returns a TokenSource that reads the token from the
+// GITHUB_TOKEN environment variable, falling back to GH_TOKEN. The
+// environment is read on every call, so rotated tokens are picked up.
+func EnvTokenSource() TokenSource {
+	return envTokenSource{}
+}
+
+// WithToken authenticates every request with a static token, such as a
+// personal access token.
+func WithToken(token string) ClientOption {
+	return WithTokenSource(StaticTokenSource(token))
+}
+
+// WithEnvToken authenticates every request with a token read from the
+// GITHUB_TOKEN or GH_TOKEN environment variables.
+func WithEnvToken() ClientOption {
+	return WithTokenSource(EnvTokenSource())
+}
+
+// WithTokenSource authenticates every request with a token from ts.
+func WithTokenSource(ts TokenSource) ClientOption {
+	return func(c *Client) error {
+		c.tokenSource = ts
+		return nil
+	}
+}
+
+// contextTokenSource is a TokenSource that can stop requesting a token
+// when a context is done, like AppTokenSource.
+type contextTokenSource interface {
+	tokenContext(ctx context.Context) (string, error)
+}
+
+// token returns the token used to authenticate a request, or an empty
+// string if the client is unauthenticated. Token sources that make
+// requests stop when ctx is done. Errors never contain the token.
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.tokenSource == nil {
+		return "", nil
+	}
+	var token string
+	var err error
+	if ts, ok := c.tokenSource.(contextTokenSource); ok {
+		token, err = ts.tokenContext(ctx)
+	} else {
+		token, err = c.tokenSource.Token()
+	}
+	if err != nil {
+		return "", &TokenSourceError{Err: err}
+	}
+	return token, nil
+}
@@ -0,0 +1,108 @@
+package checkgitci
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingTokenSource is a TokenSource that always returns an error.
+type failingTokenSource struct{}
+
+func (failingTokenSource) Token() (string, error) {
+	return "", errors.New("token endpoint unavailable")
+}
+
+func TestTokenAuthentication(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName      string
+		option        ClientOption
+		env           map[string]string
+		authorization string
+		err           error
+	}{
+		{
+			testName:      "unauthenticated",
+			option:        WithUserAgent(defaultUserAgent),
+			authorization: "",
+		},
+		{
+			testName:      "static token",
+			option:        WithToken("ghp_static"),
+			authorization: "Bearer ghp_static",
+		},
+		{
+			testName:      "GITHUB_TOKEN preferred over GH_TOKEN",
+			option:        WithEnvToken(),
+			env:           map[string]string{"GITHUB_TOKEN": "ghp_github", "GH_TOKEN": "ghp_gh"},
+			authorization: "Bearer ghp_github",
+		},
+		{
+			testName:      "GH_TOKEN fallback",
+			option:        WithEnvToken(),
+			env:           map[string]string{"GITHUB_TOKEN": "", "GH_TOKEN": "ghp_gh"},
+			authorization: "Bearer ghp_gh",
+		},
+		{
+			testName: "no token in environment",
+			option:   WithEnvToken(),
+			env:      map[string]string{"GITHUB_TOKEN": "", "GH_TOKEN": ""},
+			err:      ErrorNoToken,
+		},
+		{
+			testName: "failing token source",
+			option:   WithTokenSource(failingTokenSource{}),
+			err:      ErrorTokenSource,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		for key, value := range tc.env {
+			t.Setenv(key, value)
+		}
+
+		// Record the authorization header sent to the server.
+		authorization := ""
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}))
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server, tc.option)))
+		err := repo.GetMostRecentCommit()
+
+		// Check for the expected error.
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error to be %v but got %v", tc.testName, tc.err, err)
+		}
+
+		// Check for the expected authorization header.
+		if authorization != tc.authorization {
+			t.Errorf("%s: expected authorization to be %q but got %q", tc.testName, tc.authorization, authorization)
+		}
+	}
+}
+
+func TestTokenNotInErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server, WithToken("ghp_secret"))))
+	err := repo.MostRecentCommitWasSuccess()
+	if err == nil {
+		t.Fatal("expected an error for bad credentials")
+	}
+	if strings.Contains(err.Error(), "ghp_secret") {
+		t.Errorf("expected error not to contain the token, got %q", err.Error())
+	}
+}
@@ -0,0 +1,195 @@
+package checkgitci
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Number of responses kept by the default in-memory cache.
+const defaultCacheSize = 256
+
+// CacheEntry is a cached GitHub API response, with the validators used to
+// make conditional requests for it.
+type CacheEntry struct {
+	ETag         string
+	LastModified string
+	Header       http.Header
+	Body         []byte
+}
+
+// Cache stores GitHub API responses by url, so that repeated requests can
+// be made conditionally. GitHub does not count requests answered with 304
+// Not Modified against the rate limit. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	// Get returns the entry for key, and whether it was found.
+	Get(key string) (CacheEntry, bool)
+
+	// Set stores the entry for key. Errors are ignored by the client,
+	// since a failed cache write only costs a full request later.
+	Set(key string, entry CacheEntry) error
+}
+
+// WithCache sets the Cache used for conditional requests. Clients use an
+// in-memory cache of recent responses by default, and a nil cache turns
+// caching off.
+func WithCache(cache Cache) ClientOption {
+	return func(c *Client) error {
+		c.cache = cache
+		return nil
+	}
+}
+
+// MemoryCache is a Cache that keeps the most recently used responses
+// in memory.
+type MemoryCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// memoryCacheItem is an element of a MemoryCache's usage order.
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewMemoryCache returns a MemoryCache that holds up to capacity
+// responses, evicting the least recently used response when it is full.
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = defaultCacheSize
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// Get returns the entry for key, and marks it as recently used.
+func (m *MemoryCache) Get(key string) (CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	m.order.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores the entry for key, evicting the least recently used entry
+// if the cache is full.
+func (m *MemoryCache) Set(key string, entry CacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*memoryCacheItem).entry = entry
+		m.order.MoveToFront(elem)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	if m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+// DiskCache is a Cache that stores each response as a file in a directory,
+// so cached responses survive restarts.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache that stores responses in dir, creating
+// the directory if it does not exist.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file used to store the entry for key.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the entry for key. Missing or unreadable files are treated
+// as cache misses.
+func (d *DiskCache) Get(key string) (CacheEntry, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Set stores the entry for key. The entry is written to a temporary file
+// first, so readers never see a partially written entry.
+func (d *DiskCache) Set(key string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(d.dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.path(key))
+}
+
+// cacheLookup returns the cached entry for url, if the client has a cache
+// and the entry has a validator for a conditional request.
+func (c *Client) cacheLookup(url string) (CacheEntry, bool) {
+	if c.cache == nil {
+		return CacheEntry{}, false
+	}
+	entry, ok := c.cache.Get(url)
+	if !ok || (entry.ETag == "" && entry.LastModified == "") {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// cacheStore stores a successful response for url, if the client has a
+// cache and the response has a validator for later conditional requests.
+func (c *Client) cacheStore(url string, header http.Header, body []byte) {
+	if c.cache == nil {
+		return
+	}
+	entry := CacheEntry{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Header:       header.Clone(),
+		Body:         body,
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return
+	}
+	c.cache.Set(url, entry)
+}
@@ -0,0 +1,116 @@
+package checkgitci
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newETagServer returns a server that responds with runs and an ETag, or
+// with 304 Not Modified when the request has a matching If-None-Match
+// header. It counts full and conditional responses.
+func newETagServer(full, notModified *int) *httptest.Server {
+	return newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"runs-v1"` {
+			*notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		*full++
+		w.Header().Set("ETag", `"runs-v1"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockRunsAPI2))
+	})
+}
+
+func TestConditionalRequests(t *testing.T) {
+
+	// Setup test cases.
+	diskCache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testCases := []struct {
+		testName    string
+		opts        []ClientOption
+		full        int
+		notModified int
+	}{
+		{testName: "default memory cache", full: 1, notModified: 2},
+		{testName: "disk cache", opts: []ClientOption{WithCache(diskCache)}, full: 1, notModified: 2},
+		{testName: "caching turned off", opts: []ClientOption{WithCache(nil)}, full: 3, notModified: 0},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		full, notModified := 0, 0
+		server := newETagServer(&full, &notModified)
+		defer server.Close()
+
+		// Poll the same repository three times. Each poll should
+		// see the same runs, whether or not they were cached.
+		client := newTestClient(t, server, tc.opts...)
+		for poll := 0; poll < 3; poll++ {
+			repo := NewRepository("facebook", "react", WithClient(client))
+			if err := repo.MostRecentCommitWasSuccess(); err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.testName, err)
+			}
+			if repo.RunsResult.TotalCount != 3 || !repo.Completed || repo.Success {
+				t.Errorf("%s: unexpected runs result %+v", tc.testName, repo.RunsResult)
+			}
+		}
+
+		// Check the number of full and conditional responses.
+		if full != tc.full || notModified != tc.notModified {
+			t.Errorf("%s: expected %d full and %d conditional responses but got %d and %d",
+				tc.testName, tc.full, tc.notModified, full, notModified)
+		}
+	}
+}
+
+func TestDiskCacheSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	full, notModified := 0, 0
+	server := newETagServer(&full, &notModified)
+	defer server.Close()
+
+	// Each client opens its own cache on the same directory, like
+	// a process that is restarted between polls.
+	for poll := 0; poll < 2; poll++ {
+		cache, err := NewDiskCache(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server, WithCache(cache))))
+		if err := repo.MostRecentCommitWasSuccess(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if full != 1 || notModified != 1 {
+		t.Errorf("expected 1 full and 1 conditional response but got %d and %d", full, notModified)
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", CacheEntry{ETag: "a"})
+	cache.Set("b", CacheEntry{ETag: "b"})
+
+	// Using "a" makes "b" the least recently used entry.
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected entry a to be cached")
+	}
+	cache.Set("c", CacheEntry{ETag: "c"})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected entry b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if entry, ok := cache.Get(key); !ok || entry.ETag != key {
+			t.Errorf("expected entry %s to be cached but got %+v", key, entry)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package checkgitci
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// WithCheckSuites makes a repository also fetch the check suites for a
+// commit, so that a suite that was requested but has not created any runs
+// yet counts as incomplete, instead of as a commit with no runs.
+func WithCheckSuites() RepositoryOption {
+	return func(r *Repository) {
+		r.UseSuites = true
+	}
+}
+
+// CheckSuites queries the GitHub check-suites API endpoint for the commit
+// in the Sha field, and attaches select JSON to the Repository struct
+// SuitesResult field. CheckSuites returns an error or nil if no error.
+func (r *Repository) CheckSuites() error {
+	return r.CheckSuitesContext(context.Background())
+}
+
+// CheckSuitesContext is like CheckSuites, but stops waiting for the GitHub
+// API when ctx is cancelled or its deadline passes.
+func (r *Repository) CheckSuitesContext(ctx context.Context) error {
+	if r.Sha == "" {
+		return ErrorNoRef
+	}
+	suitesURL := addQuery(r.client().suitesURL(r.Owner, r.Name, r.Sha), url.Values{"per_page": {strconv.Itoa(perPage)}})
+
+	// Make the requests, following each page of suites.
+	var result CheckSuitesAPI
+	total, err := r.client().forEachCountedPage(ctx, suitesURL, "check_suites", func(resp *apiResponse, items json.RawMessage) error {
+		var suites []Suite
+		if err := resp.decodeItems(items, &suites); err != nil {
+			return err
+		}
+		result.CheckSuites = append(result.CheckSuites, suites...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	result.TotalCount = total
+	r.SuitesResult = result
+	return nil
+}
+
+// hasWaitingSuites reports whether any check suite has not completed and
+// has not created any check runs yet. If the repository is scoped to some
+// apps, suites from other apps are skipped.
+func (r *Repository) hasWaitingSuites() bool {
+	for _, suite := range r.SuitesResult.CheckSuites {
+		if len(r.Apps) > 0 && !containsSlug(r.Apps, suite.App.Slug) {
+			continue
+		}
+		if !suite.Status.IsCompleted() && suite.LatestCheckRunsCount == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package checkgitci
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Mock data for the check-suites API endpoint.
+var mockSuitesAPIQueued = `{
+		   "total_count": 2,
+		   "check_suites": [
+		     {
+		       "id": 5,
+		       "status": "completed",
+		       "conclusion": "success",
+		       "head_branch": "main",
+		       "head_sha": "hijklmnop",
+		       "rerequestable": true,
+		       "latest_check_runs_count": 3,
+		       "app": {"id": 15368, "slug": "github-actions", "name": "GitHub Actions"}
+		     },
+		     {
+		       "id": 6,
+		       "status": "queued",
+		       "conclusion": null,
+		       "head_branch": "main",
+		       "head_sha": "hijklmnop",
+		       "rerequestable": false,
+		       "latest_check_runs_count": 0,
+		       "app": {"id": 254, "slug": "circleci-checks", "name": "CircleCI Checks"}
+		     }
+		   ]
+		 }`
+
+var mockSuitesAPICompleted = `{
+		   "total_count": 1,
+		   "check_suites": [
+		     {
+		       "id": 5,
+		       "status": "completed",
+		       "conclusion": "success",
+		       "head_branch": "main",
+		       "head_sha": "hijklmnop",
+		       "rerequestable": true,
+		       "latest_check_runs_count": 3,
+		       "app": {"id": 15368, "slug": "github-actions", "name": "GitHub Actions"}
+		     }
+		   ]
+		 }`
+
+func TestCheckSuites(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName  string
+		useSuites bool
+		runs      string
+		suites    string
+		expected  TestResult
+	}{
+		{
+			testName: "suites ignored by default",
+			runs:     mockRunsAPINoRuns,
+			suites:   mockSuitesAPIQueued,
+			expected: TestResult{success: false, completed: true, hasCheckRuns: false},
+		},
+		{
+			testName:  "queued suite with no runs yet",
+			useSuites: true,
+			runs:      mockRunsAPINoRuns,
+			suites:    mockSuitesAPIQueued,
+			expected:  TestResult{success: false, completed: false, hasCheckRuns: false},
+		},
+		{
+			testName:  "queued suite alongside completed runs",
+			useSuites: true,
+			runs:      mockRunsAPI1,
+			suites:    mockSuitesAPIQueued,
+			expected:  TestResult{success: true, completed: false, hasCheckRuns: true},
+		},
+		{
+			testName:  "completed suites",
+			useSuites: true,
+			runs:      mockRunsAPI1,
+			suites:    mockSuitesAPICompleted,
+			expected:  TestResult{success: true, completed: true, hasCheckRuns: true},
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if strings.HasSuffix(r.URL.Path, "/check-suites") {
+				w.Write([]byte(tc.suites))
+				return
+			}
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(tc.runs))
+		})
+		defer server.Close()
+
+		opts := []RepositoryOption{WithClient(newTestClient(t, server))}
+		if tc.useSuites {
+			opts = append(opts, WithCheckSuites())
+		}
+		repo := NewRepository("facebook", "react", opts...)
+		if err := repo.MostRecentCommitWasSuccess(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.testName, err)
+		}
+
+		// Check for the expected states.
+		if repo.Success != tc.expected.success || repo.Completed != tc.expected.completed || repo.HasCheckRuns != tc.expected.hasCheckRuns {
+			t.Errorf("%s: expected success %v, completed %v, has runs %v but got %v, %v, %v", tc.testName,
+				tc.expected.success, tc.expected.completed, tc.expected.hasCheckRuns,
+				repo.Success, repo.Completed, repo.HasCheckRuns)
+		}
+
+		// Check the suite details were decoded.
+		if tc.useSuites {
+			suite := repo.SuitesResult.CheckSuites[0]
+			if suite.App.Slug != "github-actions" || suite.HeadBranch != "main" || !suite.Rerequestable {
+				t.Errorf("%s: unexpected suite %+v", tc.testName, suite)
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package checkgitci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MissingCheck is how a required check with no runs counts towards a
+// commit's success.
+type MissingCheck int
+
+const (
+	// MissingPending means a required check with no runs has not
+	// started yet, so the runs are not complete. This is the default.
+	MissingPending MissingCheck = iota
+
+	// MissingFail means a required check with no runs makes the commit
+	// unsuccessful.
+	MissingFail
+)
+
+// ChecksPolicy chooses which runs decide a commit's success, by name.
+// Names are matched with glob patterns, where "*" matches any text
+// (including "/") and "?" matches one character, or with regular
+// expressions written between slashes, like "/^test \(.*\)$/".
+type ChecksPolicy struct {
+	// Required lists the checks that decide success. If it is empty,
+	// every run counts. Otherwise, runs that match none of the patterns
+	// are ignored, and each pattern must match at least one run.
+	Required []string
+
+	// Ignored lists checks that never count, even if they are also
+	// required.
+	Ignored []string
+
+	// Missing is how a required pattern that matches no runs counts.
+	Missing MissingCheck
+}
+
+// WithChecksPolicy sets which runs decide a repository's success. For
+// example, to only require the test jobs, and ignore a coverage bot:
+//
+//	policy := checkgitci.ChecksPolicy{
+//		Required: []string{"test (*)"},
+//		Ignored:  []string{"codecov/*"},
+//	}
+//	r := checkgitci.NewRepository("owner", "name", checkgitci.WithChecksPolicy(policy))
+func WithChecksPolicy(policy ChecksPolicy) RepositoryOption {
+	return func(r *Repository) {
+		r.Checks = &policy
+	}
+}
+
+// checkMatcher matches runs against the patterns of a ChecksPolicy, and
+// the required checks from branch protection.
+type checkMatcher struct {
+	required []requiredMatcher
+	ignored  []*regexp.Regexp
+}
+
+// requiredMatcher matches the runs of one required check.
+type requiredMatcher struct {
+	label string
+	re    *regexp.Regexp
+	appID int64
+}
+
+// matches reports whether run is a run of the required check.
+func (m requiredMatcher) matches(run Run) bool {
+	return m.re.MatchString(run.Name) && (m.appID <= 0 || m.appID == run.App.ID)
+}
+
+// matcher compiles the policy's patterns. A nil policy returns a nil
+// matcher, which counts every run.
+func (p *ChecksPolicy) matcher() (*checkMatcher, error) {
+	if p == nil {
+		return nil, nil
+	}
+	m := &checkMatcher{}
+	for _, pattern := range p.Required {
+		re, err := compileCheckPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.required = append(m.required, requiredMatcher{label: pattern, re: re})
+	}
+	for _, pattern := range p.Ignored {
+		re, err := compileCheckPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.ignored = append(m.ignored, re)
+	}
+	return m, nil
+}
+
+// checksMatcher returns the matcher for the repository's checks policy,
+// with the required checks from branch protection added to it.
+func (r *Repository) checksMatcher() (*checkMatcher, error) {
+	m, err := r.Checks.matcher()
+	if err != nil || len(r.RequiredResult.Checks) == 0 {
+		return m, err
+	}
+	if m == nil {
+		m = &checkMatcher{}
+	}
+	for _, check := range r.RequiredResult.Checks {
+		m.required = append(m.required, requiredMatcher{
+			label: check.Context,
+			re:    regexp.MustCompile("^" + regexp.QuoteMeta(check.Context) + "$"),
+			appID: check.AppID,
+		})
+	}
+	return m, nil
+}
+
+// compileCheckPattern compiles a glob pattern, or a regular expression
+// written between slashes.
+func compileCheckPattern(pattern string) (*regexp.Regexp, error) {
+	expr := pattern
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expr = pattern[1 : len(pattern)-1]
+	} else {
+		var b strings.Builder
+		b.WriteString("^")
+		for _, c := range pattern {
+			switch c {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		b.WriteString("$")
+		expr = b.String()
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrorInvalidCheckPattern, pattern, err)
+	}
+	return re, nil
+}
+
+// counts reports whether a run counts towards success, and if not, why.
+func (m *checkMatcher) counts(run Run) (bool, string) {
+	if m == nil {
+		return true, ""
+	}
+	for _, re := range m.ignored {
+		if re.MatchString(run.Name) {
+			return false, "ignored by the checks policy"
+		}
+	}
+	if len(m.required) == 0 {
+		return true, ""
+	}
+	for _, required := range m.required {
+		if required.matches(run) {
+			return true, ""
+		}
+	}
+	return false, "not a required check"
+}
+
+// missingChecks returns the required checks that match none of the runs
+// that count.
+func (m *checkMatcher) missingChecks(runs []Run) []string {
+	if m == nil {
+		return nil
+	}
+	var missing []string
+	for _, required := range m.required {
+		found := false
+		for _, run := range runs {
+			if counted, _ := m.counts(run); counted && required.matches(run) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, required.label)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,147 @@
+package checkgitci
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// Mock data for required jobs, a noisy optional check, and a job that is
+// still running.
+var mockRunsAPIOptional = `{
+		   "total_count": 4,
+		   "check_runs": [
+		     {"name": "test (ubuntu)", "status": "completed", "conclusion": "success"},
+		     {"name": "test (windows)", "status": "completed", "conclusion": "success"},
+		     {"name": "codecov/patch", "status": "completed", "conclusion": "failure"},
+		     {"name": "deploy preview", "status": "in_progress", "conclusion": null}
+		   ]
+		 }`
+
+func TestChecksPolicy(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName  string
+		policy    ChecksPolicy
+		verdict   Verdict
+		missing   []string
+		success   bool
+		completed bool
+	}{
+		{
+			testName:  "every run counts without required checks",
+			policy:    ChecksPolicy{},
+			verdict:   VerdictPending,
+			completed: false,
+		},
+		{
+			testName:  "ignored checks don't count",
+			policy:    ChecksPolicy{Ignored: []string{"codecov/*", "deploy preview"}},
+			verdict:   VerdictPassed,
+			success:   true,
+			completed: true,
+		},
+		{
+			testName:  "every check ignored",
+			policy:    ChecksPolicy{Ignored: []string{"*"}},
+			verdict:   VerdictNoChecks,
+			success:   false,
+			completed: true,
+		},
+		{
+			testName:  "only required checks count",
+			policy:    ChecksPolicy{Required: []string{"test (*)"}},
+			verdict:   VerdictPassed,
+			success:   true,
+			completed: true,
+		},
+		{
+			testName:  "required checks match regular expressions",
+			policy:    ChecksPolicy{Required: []string{`/^test \((ubuntu|windows)\)$/`}},
+			verdict:   VerdictPassed,
+			success:   true,
+			completed: true,
+		},
+		{
+			testName:  "ignored checks win over required checks",
+			policy:    ChecksPolicy{Required: []string{"test (*)", "codecov/patch"}, Ignored: []string{"codecov/*"}},
+			verdict:   VerdictPending,
+			missing:   []string{"codecov/patch"},
+			completed: false,
+		},
+		{
+			testName:  "missing required check is pending",
+			policy:    ChecksPolicy{Required: []string{"test (*)", "lint"}},
+			verdict:   VerdictPending,
+			missing:   []string{"lint"},
+			completed: false,
+		},
+		{
+			testName:  "missing required check fails",
+			policy:    ChecksPolicy{Required: []string{"test (*)", "lint"}, Missing: MissingFail},
+			verdict:   VerdictFailed,
+			missing:   []string{"lint"},
+			completed: true,
+		},
+		{
+			testName:  "required check that failed",
+			policy:    ChecksPolicy{Required: []string{"test (*)", "codecov/patch"}},
+			verdict:   VerdictFailed,
+			completed: true,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunsAPIOptional))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), WithChecksPolicy(tc.policy))
+		eval := repo.EvaluateMostRecentCommit()
+		if eval.Verdict != tc.verdict {
+			t.Errorf("%s: expected verdict %q but got %q (%s)", tc.testName, tc.verdict, eval.Verdict, eval.Reason)
+		}
+		if !reflect.DeepEqual(eval.Missing, tc.missing) {
+			t.Errorf("%s: expected missing checks %q but got %q", tc.testName, tc.missing, eval.Missing)
+		}
+		if repo.Success != tc.success || repo.Completed != tc.completed {
+			t.Errorf("%s: expected success %v and completed %v but got %v and %v", tc.testName, tc.success, tc.completed, repo.Success, repo.Completed)
+		}
+	}
+}
+
+func TestChecksPolicyNoRunsYet(t *testing.T) {
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockRunsAPINoRuns))
+	})
+	defer server.Close()
+
+	// A required check means a commit without runs is still waiting
+	// for them, rather than a commit without CI.
+	repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), WithChecksPolicy(ChecksPolicy{Required: []string{"build"}}))
+	if err := repo.MostRecentCommitWasSuccess(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Success || repo.Completed {
+		t.Errorf("expected runs not to be successful or complete but got success %v and completed %v", repo.Success, repo.Completed)
+	}
+}
+
+func TestChecksPolicyInvalidPattern(t *testing.T) {
+	repo := NewRepository("facebook", "react", WithChecksPolicy(ChecksPolicy{Required: []string{"/test (/"}}))
+	if err := repo.MostRecentCommitWasSuccess(); !errors.Is(err, ErrorInvalidCheckPattern) {
+		t.Errorf("expected an invalid pattern error but got %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package checkgitci
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default base URL for the GitHub API.
+const defaultBaseURL = "https://api.github.com"
+
+// Default User-Agent header sent with every request.
+const defaultUserAgent = "check-git-ci"
+
+// Client makes requests to the GitHub API. A Client is safe for
+// concurrent use by multiple repositories, and should be created
+// with NewClient.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+	headers    http.Header
+
+	// tokenSource authenticates requests when it is not nil.
+	tokenSource TokenSource
+
+	// tlsConfig is applied to the http.Client's transport when it is not nil.
+	tlsConfig *tls.Config
+
+	// waitForRateLimit makes requests wait for rate limits to reset,
+	// for up to maxRateLimitWait.
+	waitForRateLimit bool
+	maxRateLimitWait time.Duration
+
+	// cache stores responses for conditional requests when it is not nil.
+	cache Cache
+
+	// retry configures retries of transient failures when it is not nil.
+	retry *RetryPolicy
+
+	// now and sleep are overridden in tests.
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+
+	// mu guards the fields below.
+	mu        sync.Mutex
+	rateLimit RateLimit
+}
+
+// ClientOption configures a Client created by NewClient.
+type ClientOption func(*Client) error
+
+// defaultClient is used by repositories that were not given a Client.
+var defaultClient = newClient()
+
+// newClient returns a Client with default settings.
+func newClient() *Client {
+	return &Client{
+		baseURL:    defaultBaseURL,
+		httpClient: &http.Client{},
+		userAgent:  defaultUserAgent,
+		headers:    http.Header{},
+		cache:      NewMemoryCache(defaultCacheSize),
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+}
+
+// NewClient returns a Client for the public GitHub API at api.github.com,
+// modified by any options provided. It returns an error if an
+// option could not be applied.
+func NewClient(opts ...ClientOption) (*Client, error) {
+	c := newClient()
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	// Apply TLS configuration last, so it is not lost if the
+	// http.Client is replaced by a later option.
+	if err := c.applyTLSConfig(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WithBaseURL sets the base URL used for every GitHub API request, for
+// example to point the client at a test server. The URL must be absolute.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) error {
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%w: %q", ErrorInvalidBaseURL, baseURL)
+		}
+		c.baseURL = strings.TrimSuffix(u.String(), "/")
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http.Client used to make requests, for example
+// to configure timeouts or a custom transport.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) error {
+		if httpClient == nil {
+			httpClient = &http.Client{}
+		}
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) error {
+		c.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithHeader adds a header that is sent with every request. Headers
+// added here override the default Accept and Content-Type headers.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) error {
+		c.headers.Add(key, value)
+		return nil
+	}
+}
+
+// BaseURL returns the base URL used for GitHub API requests.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// commitsURL takes a repository owner and name, and returns the url to the
+// GitHub API for viewing commmits.
+func (c *Client) commitsURL(owner, name string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/commits", c.baseURL, owner, name)
+}
+
+// commitURL takes a repository owner, name and ref, and returns the url to
+// the GitHub API for viewing the commit the ref points to.
+func (c *Client) commitURL(owner, name, ref string) string {
+	segments := strings.Split(ref, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("%s/%s", c.commitsURL(owner, name), strings.Join(segments, "/"))
+}
+
+// repoURL takes a repository owner and name, and returns the url to the
+// GitHub API for viewing the repository.
+func (c *Client) repoURL(owner, name string) string {
+	return fmt.Sprintf("%s/repos/%s/%s", c.baseURL, owner, name)
+}
+
+// protectionURL takes a repository owner, name and branch, and returns the
+// url to the GitHub API for viewing the branch's required status checks.
+func (c *Client) protectionURL(owner, name, branch string) string {
+	return fmt.Sprintf("%s/branches/%s/protection/required_status_checks", c.repoURL(owner, name), url.PathEscape(branch))
+}
+
+// rulesURL takes a repository owner, name and branch, and returns the url
+// to the GitHub API for viewing the ruleset rules that apply to the branch.
+func (c *Client) rulesURL(owner, name, branch string) string {
+	return fmt.Sprintf("%s/rules/branches/%s", c.repoURL(owner, name), url.PathEscape(branch))
+}
+
+// pullURL takes a repository owner, name and pull request number, and
+// returns the url to the GitHub API for viewing the pull request.
+func (c *Client) pullURL(owner, name string, number int) string {
+	return fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, owner, name, number)
+}
+
+// runsURL takes a repository owner, name and commit Sha, and returns the
+// url to the GitHub API for viewing check runs on that commit.
+func (c *Client) runsURL(owner, name, sha string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-runs", c.baseURL, owner, name, sha)
+}
+
+// suitesURL takes a repository owner, name and commit Sha, and returns the
+// url to the GitHub API for viewing check suites on that commit.
+func (c *Client) suitesURL(owner, name, sha string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-suites", c.baseURL, owner, name, sha)
+}
+
+// statusURL takes a repository owner, name and commit Sha, and returns the
+// url to the GitHub API for viewing the combined status of that commit.
+func (c *Client) statusURL(owner, name, sha string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/commits/%s/status", c.baseURL, owner, name, sha)
+}
+
+// apiResponse holds the parts of a successful GitHub API response.
+type apiResponse struct {
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// makeGetRequest helps make get requests. It takes a context and a url,
+// and returns the response and an error (or nil if no error). If the
+// context is cancelled or its deadline passes, the returned error wraps
+// ctx.Err(). Requests rejected by a rate limit are retried if the client
+// waits for rate limits, and transient failures are retried if the client
+// has a RetryPolicy.
+func (c *Client) makeGetRequest(ctx context.Context, url string) (*apiResponse, error) {
+	start := c.now()
+	attempt := 1
+	for {
+		resp, err := c.getOnce(ctx, url)
+		if err == nil {
+			return resp, nil
+		}
+
+		// Wait and try again if a rate limit was hit, and the
+		// client is willing to wait for it.
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if wait, ok := c.rateLimitWait(ctx, apiErr); ok {
+				if err := c.sleep(ctx, wait); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		// Otherwise, back off and try again if the error is transient.
+		wait, ok := c.retryWait(ctx, attempt, start, err)
+		if !ok {
+			return nil, err
+		}
+		if c.retry.OnRetry != nil {
+			c.retry.OnRetry(RetryEvent{URL: sanitizeURL(url), Attempt: attempt, Err: err, Wait: wait})
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		attempt++
+	}
+}
+
+// getOnce makes a single get request, and records the rate limit
+// information from the response.
+func (c *Client) getOnce(ctx context.Context, url string) (*apiResponse, error) {
+
+	// Don't start a request for a context that is already done.
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+
+	// Get the token before building the request, so that token
+	// errors are returned without making a request.
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get http request.
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add headers.
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for key, values := range c.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// Make the request conditional if the response is cached.
+	cached, isCached := c.cacheLookup(url)
+	if isCached {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	// Make request.
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx.Err())
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Record the rate limit, even for failed requests.
+	rateLimit, hasRateLimit := parseRateLimit(resp.Header)
+	if hasRateLimit {
+		c.setRateLimit(rateLimit)
+	}
+
+	// Serve the cached response if it has not been modified.
+	if isCached && resp.StatusCode == http.StatusNotModified {
+		return &apiResponse{url: url, header: cached.Header, body: cached.Body}, nil
+	}
+
+	// Check that the response was ok.
+	if resp.StatusCode != http.StatusOK {
+		apiErr := newAPIError(resp, url)
+		apiErr.RateLimit = rateLimit
+		return nil, apiErr
+	}
+
+	// Read response body into slice of bytes.
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx.Err())
+		}
+		return nil, ErrorIOReadAll
+	}
+	c.cacheStore(url, resp.Header, bodyBytes)
+	return &apiResponse{url: url, header: resp.Header, body: bodyBytes}, nil
+}
+
+// decode unmarshals the response body into v. It returns a *DecodeError
+// if the body is not JSON, or is a JSON null.
+func (resp *apiResponse) decode(v interface{}) error {
+	if bytes.Equal(bytes.TrimSpace(resp.body), []byte("null")) {
+		return resp.decodeError(errors.New("response body is null"))
+	}
+	if err := json.Unmarshal(resp.body, v); err != nil {
+		return resp.decodeError(err)
+	}
+	return nil
+}
+
+// decodeError returns a *DecodeError for the response, wrapping err.
+func (resp *apiResponse) decodeError(err error) error {
+	return &DecodeError{
+		URL:         sanitizeURL(resp.url),
+		ContentType: resp.header.Get("Content-Type"),
+		Err:         err,
+	}
+}
+
+// contextError wraps the error from a cancelled or expired context.
+func contextError(err error) error {
+	return fmt.Errorf("Error: request to GitHub API stopped: %w", err)
+}
@@ -0,0 +1,90 @@
+package checkgitci
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewClientBaseURL(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		baseURL  string
+		expected string
+		err      error
+	}{
+		{
+			testName: "trailing slash is removed",
+			baseURL:  "https://ghe.example.com/api/v3/",
+			expected: "https://ghe.example.com/api/v3",
+		},
+		{
+			testName: "relative url",
+			baseURL:  "api.github.com",
+			err:      ErrorInvalidBaseURL,
+		},
+		{
+			testName: "unsupported scheme",
+			baseURL:  "ftp://api.github.com",
+			err:      ErrorInvalidBaseURL,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		client, err := NewClient(WithBaseURL(tc.baseURL))
+
+		// Check for the expected error.
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error to be %v but got %v", tc.testName, tc.err, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		// Check for the expected base url.
+		if client.BaseURL() != tc.expected {
+			t.Errorf("%s: expected base url to be %q but got %q", tc.testName, tc.expected, client.BaseURL())
+		}
+	}
+}
+
+func TestClientRequestOptions(t *testing.T) {
+
+	// Record the headers sent to the server.
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}))
+	defer server.Close()
+
+	// Create a client with every option set.
+	client := newTestClient(t, server,
+		WithHTTPClient(&http.Client{Timeout: 5 * time.Second}),
+		WithUserAgent("octocat-checker"),
+		WithHeader("X-GitHub-Api-Version", "2022-11-28"),
+	)
+	repo := NewRepository("facebook", "react", WithClient(client))
+	if err := repo.GetMostRecentCommit(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Check that the headers made it to the server.
+	if got := received.Get("User-Agent"); got != "octocat-checker" {
+		t.Errorf("expected user agent to be %q but got %q", "octocat-checker", got)
+	}
+	if got := received.Get("X-GitHub-Api-Version"); got != "2022-11-28" {
+		t.Errorf("expected api version header to be %q but got %q", "2022-11-28", got)
+	}
+
+	// Check that the urls were built from the client base url.
+	if repo.RunsURL != server.URL+"/repos/facebook/react/commits/hijklmnop/check-runs" {
+		t.Errorf("unexpected runs url %q", repo.RunsURL)
+	}
+}
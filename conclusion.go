@@ -0,0 +1,136 @@
+package checkgitci
+
+// Status is the status of a check run or check suite. Values GitHub adds
+// in the future are kept as they are, and count as not completed.
+type Status string
+
+// Statuses reported by the GitHub check runs and check suites APIs.
+const (
+	StatusQueued     Status = "queued"
+	StatusInProgress Status = "in_progress"
+	StatusCompleted  Status = "completed"
+	StatusWaiting    Status = "waiting"
+	StatusRequested  Status = "requested"
+	StatusPending    Status = "pending"
+)
+
+// IsCompleted reports whether the status is completed.
+func (s Status) IsCompleted() bool {
+	return s == StatusCompleted
+}
+
+// Known reports whether the status is one of the statuses defined by
+// this package.
+func (s Status) Known() bool {
+	switch s {
+	case StatusQueued, StatusInProgress, StatusCompleted, StatusWaiting, StatusRequested, StatusPending:
+		return true
+	}
+	return false
+}
+
+// Conclusion is the conclusion of a completed check run or check suite.
+// It is empty until the run completes. Values GitHub adds in the future
+// are kept as they are.
+type Conclusion string
+
+// Conclusions reported by the GitHub check runs and check suites APIs.
+const (
+	ConclusionSuccess        Conclusion = "success"
+	ConclusionFailure        Conclusion = "failure"
+	ConclusionNeutral        Conclusion = "neutral"
+	ConclusionCancelled      Conclusion = "cancelled"
+	ConclusionSkipped        Conclusion = "skipped"
+	ConclusionTimedOut       Conclusion = "timed_out"
+	ConclusionActionRequired Conclusion = "action_required"
+	ConclusionStale          Conclusion = "stale"
+	ConclusionStartupFailure Conclusion = "startup_failure"
+)
+
+// Known reports whether the conclusion is one of the conclusions defined
+// by this package.
+func (c Conclusion) Known() bool {
+	switch c {
+	case ConclusionSuccess, ConclusionFailure, ConclusionNeutral, ConclusionCancelled, ConclusionSkipped,
+		ConclusionTimedOut, ConclusionActionRequired, ConclusionStale, ConclusionStartupFailure:
+		return true
+	}
+	return false
+}
+
+// Outcome is how a run's conclusion counts towards a commit's success.
+type Outcome int
+
+const (
+	// OutcomeFail means the run makes the commit unsuccessful.
+	OutcomeFail Outcome = iota
+
+	// OutcomePass means the run counts as successful.
+	OutcomePass
+
+	// OutcomeIgnore means the run does not count either way.
+	OutcomeIgnore
+
+	// OutcomePending means the run has not completed, so its outcome
+	// is not known yet.
+	OutcomePending
+)
+
+// String returns the name of the outcome.
+func (o Outcome) String() string {
+	switch o {
+	case OutcomePass:
+		return "pass"
+	case OutcomeIgnore:
+		return "ignore"
+	case OutcomePending:
+		return "pending"
+	}
+	return "fail"
+}
+
+// ConclusionPolicy maps conclusions to the outcome they count as.
+// Conclusions missing from the map, including unknown conclusions and
+// the empty conclusion of a run that has not completed, count as
+// failures.
+type ConclusionPolicy map[Conclusion]Outcome
+
+// DefaultConclusionPolicy returns the policy used by repositories without
+// one: like GitHub's default behavior, "success" and "skipped" pass, and
+// every other conclusion fails.
+func DefaultConclusionPolicy() ConclusionPolicy {
+	return ConclusionPolicy{
+		ConclusionSuccess: OutcomePass,
+		ConclusionSkipped: OutcomePass,
+	}
+}
+
+// Outcome returns the outcome for a conclusion.
+func (p ConclusionPolicy) Outcome(c Conclusion) Outcome {
+	if outcome, ok := p[c]; ok {
+		return outcome
+	}
+	return OutcomeFail
+}
+
+// WithConclusionPolicy sets which conclusions a repository counts as
+// passing, failing, or ignored. For example, to also count neutral runs as
+// passing:
+//
+//	policy := checkgitci.DefaultConclusionPolicy()
+//	policy[checkgitci.ConclusionNeutral] = checkgitci.OutcomePass
+//	r := checkgitci.NewRepository("owner", "name", checkgitci.WithConclusionPolicy(policy))
+func WithConclusionPolicy(policy ConclusionPolicy) RepositoryOption {
+	return func(r *Repository) {
+		r.Conclusions = policy
+	}
+}
+
+// conclusionPolicy returns the repository's conclusion policy, falling
+// back to the default policy if none was set.
+func (r *Repository) conclusionPolicy() ConclusionPolicy {
+	if r.Conclusions == nil {
+		return DefaultConclusionPolicy()
+	}
+	return r.Conclusions
+}
@@ -0,0 +1,124 @@
+package checkgitci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// mockRunsWithConclusions returns check-runs API JSON with one completed
+// run for each conclusion.
+func mockRunsWithConclusions(conclusions ...Conclusion) string {
+	runs := make([]Run, 0, len(conclusions))
+	for i, conclusion := range conclusions {
+		runs = append(runs, Run{Name: fmt.Sprintf("job %d", i), Status: StatusCompleted, Conclusion: conclusion})
+	}
+	body, _ := json.Marshal(CheckRunsAPI{TotalCount: len(runs), CheckRuns: runs})
+	return string(body)
+}
+
+func TestConclusionPolicy(t *testing.T) {
+
+	// A policy where neutral passes and cancelled runs are ignored.
+	lenient := DefaultConclusionPolicy()
+	lenient[ConclusionNeutral] = OutcomePass
+	lenient[ConclusionCancelled] = OutcomeIgnore
+
+	// Setup test cases.
+	testCases := []struct {
+		testName    string
+		policy      ConclusionPolicy
+		conclusions []Conclusion
+		success     bool
+	}{
+		{
+			testName:    "default policy passes success and skipped",
+			conclusions: []Conclusion{ConclusionSuccess, ConclusionSkipped},
+			success:     true,
+		},
+		{
+			testName:    "default policy fails neutral",
+			conclusions: []Conclusion{ConclusionSuccess, ConclusionNeutral},
+			success:     false,
+		},
+		{
+			testName:    "default policy fails unknown conclusions",
+			conclusions: []Conclusion{ConclusionSuccess, "brand_new_conclusion"},
+			success:     false,
+		},
+		{
+			testName:    "lenient policy passes neutral",
+			policy:      lenient,
+			conclusions: []Conclusion{ConclusionSuccess, ConclusionNeutral},
+			success:     true,
+		},
+		{
+			testName:    "lenient policy ignores cancelled",
+			policy:      lenient,
+			conclusions: []Conclusion{ConclusionSuccess, ConclusionCancelled},
+			success:     true,
+		},
+		{
+			testName:    "policy that ignores every run",
+			policy:      ConclusionPolicy{ConclusionSuccess: OutcomeIgnore},
+			conclusions: []Conclusion{ConclusionSuccess, ConclusionSuccess},
+			success:     false,
+		},
+		{
+			testName:    "lenient policy still fails timed out",
+			policy:      lenient,
+			conclusions: []Conclusion{ConclusionNeutral, ConclusionTimedOut},
+			success:     false,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunsWithConclusions(tc.conclusions...)))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), WithConclusionPolicy(tc.policy))
+		if err := repo.MostRecentCommitWasSuccess(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.testName, err)
+		}
+		if repo.Success != tc.success || !repo.Completed {
+			t.Errorf("%s: expected success to be %v but got %v", tc.testName, tc.success, repo.Success)
+		}
+	}
+}
+
+func TestStatusTaxonomy(t *testing.T) {
+
+	// Every status other than completed, including unknown
+	// ones, means the runs are not complete.
+	for _, status := range []Status{StatusQueued, StatusInProgress, StatusWaiting, StatusRequested, StatusPending, "brand_new_status"} {
+		repo := &Repository{
+			HasCheckRuns: true,
+			RunsResult:   CheckRunsAPI{TotalCount: 2, CheckRuns: []Run{{Status: StatusCompleted}, {Status: status}}},
+		}
+		repo.RunsAreComplete()
+		if repo.Completed {
+			t.Errorf("%q: expected runs not to be complete", status)
+		}
+	}
+}
+
+func TestUnknownValuesArePreserved(t *testing.T) {
+	var run Run
+	if err := json.Unmarshal([]byte(`{"status": "paused", "conclusion": "vetoed"}`), &run); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run.Status != "paused" || run.Status.Known() || run.Conclusion != "vetoed" || run.Conclusion.Known() {
+		t.Errorf("expected unknown values to be preserved but got %+v", run)
+	}
+	if !StatusCompleted.Known() || !ConclusionStartupFailure.Known() {
+		t.Error("expected defined values to be known")
+	}
+}
@@ -3,9 +3,15 @@ package checkgitci
 import "errors"
 
 // ErrorFailedAPICall is returned when we receive an error or bad response
-// from the GitHub API.
+// from the GitHub API. Bad responses are returned as an *APIError, which
+// matches ErrorFailedAPICall with errors.Is.
 var ErrorFailedAPICall = errors.New("Error: bad Response from GitHub API")
 
+// ErrorDecodeResponse is returned when a response from the GitHub API
+// cannot be decoded. It is returned as a *DecodeError, which matches
+// ErrorDecodeResponse with errors.Is.
+var ErrorDecodeResponse = errors.New("Error: unable to decode response from GitHub API")
+
 // ErrorIOReadAll is returned when we receive an error on an io.ReadAll call.
 var ErrorIOReadAll = errors.New("Error: io readall error")
 
@@ -16,3 +22,50 @@ var ErrorNoRepositoryName = errors.New("Error: repository name field cannot be b
 // ErrorNoRepositoryOwner is returned when trying to perform an operation that requires
 // a repository owner that has not yet been set.
 var ErrorNoRepositoryOwner = errors.New("Error: repository owner field cannot be blank")
+
+// ErrorInvalidBaseURL is returned when a Client is given a base URL that
+// is not an absolute http or https URL.
+var ErrorInvalidBaseURL = errors.New("Error: invalid GitHub API base URL")
+
+// ErrorNoToken is returned by EnvTokenSource when neither the GITHUB_TOKEN
+// nor the GH_TOKEN environment variable is set.
+var ErrorNoToken = errors.New("Error: no GitHub token found in GITHUB_TOKEN or GH_TOKEN")
+
+// ErrorTokenSource is returned when a TokenSource fails to supply a token.
+var ErrorTokenSource = errors.New("Error: unable to get GitHub token")
+
+// ErrorInvalidPrivateKey is returned when a GitHub App private key is not
+// a PEM encoded RSA private key.
+var ErrorInvalidPrivateKey = errors.New("Error: invalid GitHub App private key")
+
+// ErrorInvalidCABundle is returned when a CA bundle does not contain any
+// PEM encoded certificates.
+var ErrorInvalidCABundle = errors.New("Error: no certificates found in CA bundle")
+
+// ErrorUnsupportedTransport is returned when TLS configuration is given
+// for an http.Client whose transport is not an *http.Transport.
+var ErrorUnsupportedTransport = errors.New("Error: TLS configuration requires an *http.Transport")
+
+// ErrorIncompleteResults is returned when a paginated GitHub API listing
+// does not contain as many items as it reported.
+var ErrorIncompleteResults = errors.New("Error: incomplete results from GitHub API")
+
+// ErrorNoRef is returned when trying to resolve a blank ref, or when a ref
+// does not resolve to a commit.
+var ErrorNoRef = errors.New("Error: ref does not resolve to a commit")
+
+// ErrorInvalidPullRequest is returned when a pull request number is not
+// positive, or the pull request has no head commit.
+var ErrorInvalidPullRequest = errors.New("Error: invalid pull request")
+
+// ErrorInvalidCheckPattern is returned when a pattern in a ChecksPolicy is
+// not a valid regular expression.
+var ErrorInvalidCheckPattern = errors.New("Error: invalid check name pattern")
+
+// ErrorProtectionAccess is returned when the token does not have
+// permission to read a branch's protection rule.
+var ErrorProtectionAccess = errors.New("Error: no permission to read branch protection")
+
+// ErrorWaitTimeout is returned when waiting for CI runs to complete times
+// out, and is returned as a *WaitTimeoutError.
+var ErrorWaitTimeout = errors.New("Error: timed out waiting for CI runs to complete")
@@ -0,0 +1,93 @@
+package checkgitci
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+)
+
+// REST API path prefix on GitHub Enterprise Server hosts.
+const enterpriseAPIPath = "/api/v3"
+
+// WithEnterprise points the client at a GitHub Enterprise Server host, such
+// as "ghe.example.com" or "https://ghe.example.com". The REST API prefix
+// (/api/v3) is added if the host does not already include it, and https
+// is assumed if no scheme is given.
+func WithEnterprise(host string) ClientOption {
+	return func(c *Client) error {
+		return WithBaseURL(enterpriseBaseURL(host))(c)
+	}
+}
+
+// enterpriseBaseURL derives the REST API base URL for a GitHub Enterprise
+// Server host.
+func enterpriseBaseURL(host string) string {
+	base := strings.TrimSpace(host)
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+	base = strings.TrimRight(base, "/")
+	if !strings.HasSuffix(base, enterpriseAPIPath) {
+		base += enterpriseAPIPath
+	}
+	return base
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to GitHub, for
+// example to present a client certificate to an Enterprise Server host.
+// The configuration is applied to a copy of the client's transport, which
+// must be an *http.Transport.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) error {
+		c.tlsConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithRootCAs trusts the PEM encoded certificates in caBundle, in addition
+// to the system certificate pool, for example when an Enterprise Server
+// host uses a self-signed certificate.
+func WithRootCAs(caBundle []byte) ClientOption {
+	return func(c *Client) error {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return ErrorInvalidCABundle
+		}
+		if c.tlsConfig == nil {
+			c.tlsConfig = &tls.Config{}
+		}
+		c.tlsConfig.RootCAs = pool
+		return nil
+	}
+}
+
+// applyTLSConfig sets the client's TLS configuration on a copy of its
+// http.Client and transport, so the caller's http.Client is not modified.
+func (c *Client) applyTLSConfig() error {
+	if c.tlsConfig == nil {
+		return nil
+	}
+
+	// Copy the transport, starting from the default transport if
+	// the http.Client does not have one.
+	var transport *http.Transport
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return ErrorUnsupportedTransport
+	}
+	transport.TLSClientConfig = c.tlsConfig
+
+	// Copy the http.Client with the new transport.
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
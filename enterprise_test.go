@@ -0,0 +1,97 @@
+package checkgitci
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnterpriseBaseURL(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		host     string
+		expected string
+	}{
+		{host: "ghe.example.com", expected: "https://ghe.example.com/api/v3"},
+		{host: "https://ghe.example.com", expected: "https://ghe.example.com/api/v3"},
+		{host: "https://ghe.example.com/", expected: "https://ghe.example.com/api/v3"},
+		{host: "https://ghe.example.com/api/v3/", expected: "https://ghe.example.com/api/v3"},
+		{host: "http://ghe.internal:8080", expected: "http://ghe.internal:8080/api/v3"},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		client, err := NewClient(WithEnterprise(tc.host))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.host, err)
+			continue
+		}
+		if client.BaseURL() != tc.expected {
+			t.Errorf("%s: expected base url to be %q but got %q", tc.host, tc.expected, client.BaseURL())
+		}
+	}
+}
+
+func TestEnterpriseSelfSignedCertificate(t *testing.T) {
+
+	// Stand in for an Enterprise Server host with a self-signed certificate.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/octo-org/octo-repo/commits" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// Without the CA bundle, the certificate should not be trusted.
+	client, err := NewClient(WithEnterprise(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo := NewRepository("octo-org", "octo-repo", WithClient(client))
+	if err := repo.GetMostRecentCommit(); err == nil {
+		t.Error("expected certificate error without CA bundle")
+	}
+
+	// With the CA bundle, the request should succeed.
+	client, err = NewClient(WithEnterprise(server.URL), WithRootCAs(caBundle))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	repo = NewRepository("octo-org", "octo-repo", WithClient(client))
+	if err := repo.GetMostRecentCommit(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Sha != "hijklmnop" {
+		t.Errorf("expected sha to be %q but got %q", "hijklmnop", repo.Sha)
+	}
+	if repo.RunsURL != server.URL+"/api/v3/repos/octo-org/octo-repo/commits/hijklmnop/check-runs" {
+		t.Errorf("unexpected runs url %q", repo.RunsURL)
+	}
+}
+
+func TestRootCAsErrors(t *testing.T) {
+	if _, err := NewClient(WithRootCAs([]byte("not a certificate"))); !errors.Is(err, ErrorInvalidCABundle) {
+		t.Errorf("expected error to be %v but got %v", ErrorInvalidCABundle, err)
+	}
+
+	// Custom transports cannot be given TLS configuration.
+	httpClient := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	if _, err := NewClient(WithHTTPClient(httpClient), WithTLSConfig(&tls.Config{})); !errors.Is(err, ErrorUnsupportedTransport) {
+		t.Errorf("expected error to be %v but got %v", ErrorUnsupportedTransport, err)
+	}
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
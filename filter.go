@@ -0,0 +1,88 @@
+package checkgitci
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// FilterMode chooses which check runs the check runs API returns for a
+// commit.
+type FilterMode string
+
+const (
+	// FilterLatest returns the most recent check runs. This is
+	// GitHub's default.
+	FilterLatest FilterMode = "latest"
+
+	// FilterAll returns every check run, including those from earlier
+	// check suites.
+	FilterAll FilterMode = "all"
+)
+
+// RunsFilter narrows the check runs returned by the check runs API. Zero
+// fields don't narrow the runs.
+type RunsFilter struct {
+	// AppID only returns check runs created by the GitHub App with this
+	// ID, such as 15368 for GitHub Actions.
+	AppID int64
+
+	// CheckName only returns check runs with this name.
+	CheckName string
+
+	// Filter chooses between the latest check runs and all of them.
+	Filter FilterMode
+}
+
+// WithRunsFilter narrows the check runs a repository fetches, using the
+// check runs API's app_id, check_name and filter query parameters.
+func WithRunsFilter(filter RunsFilter) RepositoryOption {
+	return func(r *Repository) {
+		r.RunsFilter = filter
+	}
+}
+
+// query returns the query parameters for the filter.
+func (f RunsFilter) query() url.Values {
+	query := url.Values{}
+	if f.AppID != 0 {
+		query.Set("app_id", strconv.FormatInt(f.AppID, 10))
+	}
+	if f.CheckName != "" {
+		query.Set("check_name", f.CheckName)
+	}
+	if f.Filter != "" {
+		query.Set("filter", string(f.Filter))
+	}
+	return query
+}
+
+// WithApps makes a repository only count check runs created by the GitHub
+// Apps with the given slugs, such as "github-actions". Commit statuses are
+// not created by apps, so they don't count either, and check suites from
+// other apps are not waited for.
+func WithApps(slugs ...string) RepositoryOption {
+	return func(r *Repository) {
+		r.Apps = slugs
+	}
+}
+
+// appRuns returns the runs created by any of the apps with the given slugs.
+func appRuns(runs []Run, slugs []string) []Run {
+	var scoped []Run
+	for _, run := range runs {
+		if run.Origin == OriginCheckRun && containsSlug(slugs, run.App.Slug) {
+			scoped = append(scoped, run)
+		}
+	}
+	return scoped
+}
+
+// containsSlug reports whether slug is one of slugs.
+func containsSlug(slugs []string, slug string) bool {
+	for _, s := range slugs {
+		if s == slug {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package checkgitci
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunsFilter(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		filter   RunsFilter
+		query    string
+	}{
+		{
+			testName: "no filter",
+			query:    "per_page=100",
+		},
+		{
+			testName: "every filter",
+			filter:   RunsFilter{AppID: 15368, CheckName: "test (ubuntu)", Filter: FilterAll},
+			query:    "app_id=15368&check_name=test+%28ubuntu%29&filter=all&per_page=100",
+		},
+		{
+			testName: "latest runs",
+			filter:   RunsFilter{Filter: FilterLatest},
+			query:    "filter=latest&per_page=100",
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		var query string
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunsAPI1))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), WithRunsFilter(tc.filter))
+		if err := repo.MostRecentCommitWasSuccess(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.testName, err)
+		}
+		if query != tc.query {
+			t.Errorf("%s: expected query %q but got %q", tc.testName, tc.query, query)
+		}
+	}
+}
+
+func TestWithApps(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		opts     []RepositoryOption
+		verdict  Verdict
+		runCount int
+	}{
+		{
+			testName: "every app",
+			verdict:  VerdictPending,
+			runCount: 3,
+		},
+		{
+			testName: "only CircleCI",
+			opts:     []RepositoryOption{WithApps("circleci-checks")},
+			verdict:  VerdictPassed,
+			runCount: 1,
+		},
+		{
+			testName: "only GitHub Actions",
+			opts:     []RepositoryOption{WithApps("github-actions"), WithLatestAttempts()},
+			verdict:  VerdictPending,
+			runCount: 1,
+		},
+		{
+			testName: "app without runs",
+			opts:     []RepositoryOption{WithApps("dependabot")},
+			verdict:  VerdictNoChecks,
+		},
+		{
+			testName: "statuses are not from apps",
+			opts:     []RepositoryOption{WithApps("circleci-checks"), WithSources(CheckRunsAndStatuses)},
+			verdict:  VerdictPassed,
+			runCount: 1,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if r.URL.Path == "/repos/facebook/react/commits/hijklmnop/status" {
+				w.Write([]byte(`{"state": "failure", "total_count": 1, "statuses": [{"context": "ci/jenkins", "state": "failure"}]}`))
+				return
+			}
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunsAPIRerunQueued))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", append([]RepositoryOption{WithClient(newTestClient(t, server))}, tc.opts...)...)
+		eval := repo.EvaluateMostRecentCommit()
+		if eval.Verdict != tc.verdict {
+			t.Errorf("%s: expected verdict %q but got %q (%s)", tc.testName, tc.verdict, eval.Verdict, eval.Reason)
+		}
+		if len(eval.Runs) != tc.runCount {
+			t.Errorf("%s: expected %d runs but got %d", tc.testName, tc.runCount, len(eval.Runs))
+		}
+		for _, result := range eval.Runs {
+			if result.Run.App.Slug == "" {
+				t.Errorf("%s: expected run %q to have an app", tc.testName, result.Run.Name)
+			}
+		}
+	}
+}
+
+func TestWithAppsSkipsOtherSuites(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName  string
+		apps      []string
+		verdict   Verdict
+		completed bool
+	}{
+		{
+			testName:  "queued suite from another app",
+			apps:      []string{"github-actions"},
+			verdict:   VerdictPassed,
+			completed: true,
+		},
+		{
+			testName:  "queued suite from a scoped app",
+			apps:      []string{"github-actions", "circleci-checks"},
+			verdict:   VerdictPending,
+			completed: false,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if strings.HasSuffix(r.URL.Path, "/check-suites") {
+				w.Write([]byte(mockSuitesAPIQueued))
+				return
+			}
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunsAPIRerun))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)),
+			WithApps(tc.apps...), WithCheckSuites(), WithLatestAttempts())
+		eval := repo.EvaluateMostRecentCommit()
+		if eval.Verdict != tc.verdict {
+			t.Errorf("%s: expected verdict %q but got %q (%s)", tc.testName, tc.verdict, eval.Verdict, eval.Reason)
+		}
+		if repo.Completed != tc.completed || !repo.Success {
+			t.Errorf("%s: expected success and completed %v but got %v and %v", tc.testName, tc.completed, repo.Success, repo.Completed)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package checkgitci
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Installation tokens are refreshed this long before they expire, so a
+// token never expires in the middle of a request.
+const appTokenRefreshWindow = time.Minute
+
+// App JWTs are backdated to allow for clock drift, and expire well within
+// the ten minute maximum allowed by GitHub.
+const (
+	appJWTBackdate = time.Minute
+	appJWTLifetime = 9 * time.Minute
+)
+
+// AppTokenSource is a TokenSource that authenticates as a GitHub App
+// installation. It signs a JWT with the App's private key, exchanges it
+// for an installation access token, and caches that token until shortly
+// before it expires.
+type AppTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+
+	// client supplies the base URL and http.Client used to request
+	// installation tokens.
+	client *Client
+
+	// now returns the current time, and is overridden in tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+
+	// refresh is the token request in flight, if there is one.
+	refresh *tokenRefresh
+}
+
+// tokenRefresh is a token request shared by every caller that needs a new
+// token while it is in flight. done is closed when it finishes.
+type tokenRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// installationTokenAPI holds selected information from the GitHub
+// installation access tokens API.
+type installationTokenAPI struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewAppTokenSource returns a TokenSource for a GitHub App installation,
+// given the App ID, the installation ID, and the App's PEM encoded private
+// key. Client options (like WithBaseURL or WithHTTPClient) configure how
+// installation tokens are requested.
+func NewAppTokenSource(appID, installationID int64, privateKey []byte, opts ...ClientOption) (*AppTokenSource, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &AppTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		client:         client,
+		now:            time.Now,
+	}, nil
+}
+
+// WithAppInstallation authenticates every request as a GitHub App
+// installation, given the App ID, the installation ID, and the App's PEM
+// encoded private key. Installation tokens are requested with the same
+// base URL and http.Client as the rest of the client's requests.
+func WithAppInstallation(appID, installationID int64, privateKey []byte) ClientOption {
+	return func(c *Client) error {
+		key, err := parsePrivateKey(privateKey)
+		if err != nil {
+			return err
+		}
+		c.tokenSource = &AppTokenSource{
+			appID:          appID,
+			installationID: installationID,
+			key:            key,
+			client:         c,
+			now:            time.Now,
+		}
+		return nil
+	}
+}
+
+// Token returns a cached installation access token, requesting a new one
+// if there is no token or it is about to expire.
+func (s *AppTokenSource) Token() (string, error) {
+	return s.tokenContext(context.Background())
+}
+
+// tokenContext is like Token, but stops waiting for a new token when ctx
+// is cancelled or its deadline passes. Only one caller requests a new
+// token at a time, and the others wait for it without holding the lock.
+func (s *AppTokenSource) tokenContext(ctx context.Context) (string, error) {
+	for {
+		s.mu.Lock()
+
+		// Reuse the cached token if it is still valid.
+		if s.token != "" && s.now().Add(appTokenRefreshWindow).Before(s.expires) {
+			token := s.token
+			s.mu.Unlock()
+			return token, nil
+		}
+
+		// Wait for a request in flight. If it was stopped by the
+		// context of the caller that made it, try again.
+		if refresh := s.refresh; refresh != nil {
+			s.mu.Unlock()
+			select {
+			case <-refresh.done:
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+			if refresh.err != nil && !isContextError(refresh.err) {
+				return "", refresh.err
+			}
+			continue
+		}
+
+		// Otherwise, request a new one.
+		refresh := &tokenRefresh{done: make(chan struct{})}
+		s.refresh = refresh
+		s.mu.Unlock()
+		result, err := s.requestToken(ctx)
+
+		s.mu.Lock()
+		s.refresh = nil
+		refresh.err = err
+		if err == nil {
+			s.token = result.Token
+			s.expires = result.ExpiresAt
+		}
+		s.mu.Unlock()
+		close(refresh.done)
+		if err != nil {
+			return "", err
+		}
+		return result.Token, nil
+	}
+}
+
+// isContextError reports whether err was caused by a context being
+// cancelled or its deadline passing.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// requestToken exchanges a signed JWT for an installation access token.
+func (s *AppTokenSource) requestToken(ctx context.Context) (*installationTokenAPI, error) {
+	jwt, err := s.signJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get http request.
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", s.client.baseURL, s.installationID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add headers.
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	if s.client.userAgent != "" {
+		req.Header.Set("User-Agent", s.client.userAgent)
+	}
+
+	// Make request.
+	resp, err := s.client.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// GitHub responds with 201 Created when a token is issued.
+	if resp.StatusCode != http.StatusCreated {
+		return nil, newAPIError(resp, url)
+	}
+
+	// Read and decode the response body.
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ErrorIOReadAll
+	}
+	var result installationTokenAPI
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode installation token", ErrorFailedAPICall)
+	}
+	if result.Token == "" {
+		return nil, fmt.Errorf("%w: installation token response has no token", ErrorFailedAPICall)
+	}
+	return &result, nil
+}
+
+// signJWT returns a JWT signed with the App's private key, as required to
+// authenticate as the App itself.
+func (s *AppTokenSource) signJWT() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-appJWTBackdate).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Sign the encoded header and claims.
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey parses a PEM encoded RSA private key, in either the
+// PKCS #1 format GitHub generates or the PKCS #8 format.
+func parsePrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, ErrorInvalidPrivateKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrorInvalidPrivateKey
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrorInvalidPrivateKey
+	}
+	return key, nil
+}
@@ -0,0 +1,237 @@
+package checkgitci
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestAppKey returns a new RSA key, and the key PEM encoded
+// the way GitHub generates App private keys.
+func newTestAppKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return key, encoded
+}
+
+// verifyTestJWT checks that a JWT was signed by key, and returns its claims.
+func verifyTestJWT(key *rsa.PrivateKey, jwt string) (map[string]int64, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwt does not have three parts")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+		return nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var claims map[string]int64
+	err = json.Unmarshal(payload, &claims)
+	return claims, err
+}
+
+// newTestAppServer returns a server that stands in for the installation
+// token endpoint and the commits API. Each exchange issues a new token,
+// valid for lifetime, and is counted in exchanges.
+func newTestAppServer(t *testing.T, key *rsa.PrivateKey, lifetime time.Duration, exchanges *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/app/installations/99/access_tokens" {
+			if r.Method != "POST" {
+				t.Errorf("expected POST to token endpoint but got %s", r.Method)
+			}
+			claims, err := verifyTestJWT(key, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			if err != nil || claims["iss"] != 42 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			*exchanges++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, *exchanges, time.Now().Add(lifetime).Format(time.RFC3339))
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ghs_") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}))
+}
+
+func TestAppInstallationAuthentication(t *testing.T) {
+	key, encoded := newTestAppKey(t)
+	exchanges := 0
+	server := newTestAppServer(t, key, time.Hour, &exchanges)
+	defer server.Close()
+
+	// Make two requests with the same client.
+	client := newTestClient(t, server, WithAppInstallation(42, 99, encoded))
+	repo := NewRepository("octo-org", "octo-repo", WithClient(client))
+	for i := 0; i < 2; i++ {
+		if err := repo.GetMostRecentCommit(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The installation token should have been cached between requests.
+	if exchanges != 1 {
+		t.Errorf("expected 1 token exchange but got %d", exchanges)
+	}
+}
+
+func TestAppTokenSourceRefresh(t *testing.T) {
+	key, encoded := newTestAppKey(t)
+	exchanges := 0
+	server := newTestAppServer(t, key, 30*time.Minute, &exchanges)
+	defer server.Close()
+
+	ts, err := NewAppTokenSource(42, 99, encoded, WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Get a token, then move the clock forward to just before
+	// expiry, and get another one.
+	first, err := ts.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts.now = func() time.Time { return time.Now().Add(30*time.Minute - appTokenRefreshWindow/2) }
+	second, err := ts.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The token should have been refreshed.
+	if first == second || exchanges != 2 {
+		t.Errorf("expected token to be refreshed, got %q then %q after %d exchanges", first, second, exchanges)
+	}
+}
+
+func TestAppTokenSourceErrors(t *testing.T) {
+	// A key that is not PEM encoded should be rejected.
+	if _, err := NewAppTokenSource(42, 99, []byte("not a key")); !errors.Is(err, ErrorInvalidPrivateKey) {
+		t.Errorf("expected error to be %v but got %v", ErrorInvalidPrivateKey, err)
+	}
+
+	// A token endpoint that rejects the JWT should fail the request.
+	key, _ := newTestAppKey(t)
+	_, otherKey := newTestAppKey(t)
+	exchanges := 0
+	server := newTestAppServer(t, key, time.Hour, &exchanges)
+	defer server.Close()
+
+	client := newTestClient(t, server, WithAppInstallation(42, 99, otherKey))
+	repo := NewRepository("octo-org", "octo-repo", WithClient(client))
+	err := repo.GetMostRecentCommit()
+	if !errors.Is(err, ErrorTokenSource) || !errors.Is(err, ErrorFailedAPICall) {
+		t.Errorf("expected token source error from failed api call but got %v", err)
+	}
+}
+
+func TestAppTokenExchangeContext(t *testing.T) {
+	_, encoded := newTestAppKey(t)
+
+	// The token endpoint never answers until the test ends.
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// The request's deadline also stops the token exchange.
+	client := newTestClient(t, server, WithAppInstallation(42, 99, encoded))
+	repo := NewRepository("octo-org", "octo-repo", WithClient(client))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := repo.MostRecentCommitWasSuccessContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrorTokenSource) {
+		t.Errorf("expected a token source deadline error but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the token exchange to stop at the deadline but it took %s", elapsed)
+	}
+}
+
+func TestAppTokenWaitContext(t *testing.T) {
+	_, encoded := newTestAppKey(t)
+
+	// The token endpoint answers once released.
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	exchanges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		exchanges++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, exchanges, time.Now().Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer server.Close()
+	source, err := NewAppTokenSource(42, 99, encoded, WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// One caller requests a token without a deadline.
+	type result struct {
+		token string
+		err   error
+	}
+	first := make(chan result, 1)
+	go func() {
+		token, err := source.Token()
+		first <- result{token, err}
+	}()
+	<-started
+
+	// Another caller's deadline stops its wait for the same token.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := source.tokenContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the wait to stop at the deadline but it took %s", elapsed)
+	}
+
+	// The first caller's token is shared once it arrives.
+	close(release)
+	got := <-first
+	if got.err != nil || got.token != "ghs_1" {
+		t.Fatalf("expected token ghs_1 but got %q: %v", got.token, got.err)
+	}
+	if token, err := source.tokenContext(context.Background()); err != nil || token != "ghs_1" || exchanges != 1 {
+		t.Errorf("expected cached token ghs_1 from 1 exchange but got %q from %d: %v", token, exchanges, err)
+	}
+}
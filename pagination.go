@@ -0,0 +1,155 @@
+package checkgitci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Page size requested from paginated GitHub API endpoints. 100 is the
+// largest page size GitHub allows.
+const perPage = 100
+
+// Maximum number of pages followed for one listing, to guard against a
+// Link header that never ends.
+const maxPages = 100
+
+// forEachPage requests rawURL, and then each page after it named by the
+// response's Link header, calling fn with each response in order.
+func (c *Client) forEachPage(ctx context.Context, rawURL string, fn func(*apiResponse) error) error {
+	next := rawURL
+	for page := 0; next != ""; page++ {
+		if page == maxPages {
+			return fmt.Errorf("%w: more than %d pages from %s", ErrorIncompleteResults, maxPages, sanitizeURL(rawURL))
+		}
+		resp, err := c.makeGetRequest(ctx, next)
+		if err != nil {
+			return err
+		}
+		if err := fn(resp); err != nil {
+			return err
+		}
+		next = resp.nextPageURL()
+	}
+	return nil
+}
+
+// forEachCountedPage requests a listing like forEachPage, for endpoints
+// whose pages hold a total_count and an array of items under itemsKey,
+// like "check_runs". Both fields are required, so that a body missing
+// them (like an error page) is never mistaken for an empty listing. Once
+// every page is read, the number of items must match the last page's
+// total_count, since a missing item could be a failure. Items created
+// while the pages are read (like runs started by CI) can shift them, so
+// the listing is read once more before giving up. fn is then called with
+// each response and its items, and the total count is returned.
+func (c *Client) forEachCountedPage(ctx context.Context, rawURL, itemsKey string, fn func(resp *apiResponse, items json.RawMessage) error) (int, error) {
+	pages, total, collected, err := c.countedPages(ctx, rawURL, itemsKey)
+	if err == nil && collected != total {
+		pages, total, collected, err = c.countedPages(ctx, rawURL, itemsKey)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if collected != total {
+		return 0, fmt.Errorf("%w: got %d of %d %s", ErrorIncompleteResults, collected, total, strings.ReplaceAll(itemsKey, "_", " "))
+	}
+	for _, page := range pages {
+		if err := fn(page.resp, page.items); err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// countedPage is a page read by countedPages.
+type countedPage struct {
+	resp  *apiResponse
+	items json.RawMessage
+}
+
+// countedPages reads every page of a listing for forEachCountedPage, and
+// returns them with the last page's total_count and the number of items
+// read.
+func (c *Client) countedPages(ctx context.Context, rawURL, itemsKey string) ([]countedPage, int, int, error) {
+	var pages []countedPage
+	total, collected := 0, 0
+	err := c.forEachPage(ctx, rawURL, func(resp *apiResponse) error {
+		var page map[string]json.RawMessage
+		if err := resp.decode(&page); err != nil {
+			return err
+		}
+		count, items := page["total_count"], page[itemsKey]
+		if isMissing(count) || isMissing(items) {
+			return resp.decodeError(fmt.Errorf("missing total_count or %s", itemsKey))
+		}
+		if err := json.Unmarshal(count, &total); err != nil {
+			return resp.decodeError(err)
+		}
+		var list []json.RawMessage
+		if err := resp.decodeItems(items, &list); err != nil {
+			return err
+		}
+		collected += len(list)
+		pages = append(pages, countedPage{resp: resp, items: items})
+		return nil
+	})
+	return pages, total, collected, err
+}
+
+// isMissing reports whether a JSON field is absent or null.
+func isMissing(field json.RawMessage) bool {
+	return field == nil || bytes.Equal(field, []byte("null"))
+}
+
+// decodeItems decodes the items of a page from forEachCountedPage.
+func (resp *apiResponse) decodeItems(items json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(items, v); err != nil {
+		return resp.decodeError(err)
+	}
+	return nil
+}
+
+// nextPageURL returns the url of the next page named by the response's
+// Link header, or an empty string if this is the last page.
+func (resp *apiResponse) nextPageURL() string {
+	// The header looks like:
+	// <https://api.github.com/...?page=2>; rel="next", <...>; rel="last"
+	for _, link := range strings.Split(resp.header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "rel=") {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(strings.TrimPrefix(param, "rel="), `"`)) {
+				if rel == "next" {
+					return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// addQuery returns rawURL with the given query parameters set, replacing
+// any existing values for the same keys.
+func addQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,264 @@
+package checkgitci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// mockRunsPage returns a page of check-runs API JSON with count runs,
+// starting at run number first. The run numbered failed has failed.
+func mockRunsPage(totalCount, first, count, failed int) string {
+	runs := make([]map[string]string, 0, count)
+	for i := first; i < first+count; i++ {
+		conclusion := "success"
+		if i == failed {
+			conclusion = "failure"
+		}
+		runs = append(runs, map[string]string{
+			"name":       fmt.Sprintf("matrix job %d", i),
+			"status":     "completed",
+			"conclusion": conclusion,
+		})
+	}
+	body, _ := json.Marshal(map[string]interface{}{"total_count": totalCount, "check_runs": runs})
+	return string(body)
+}
+
+func TestCheckRunsPagination(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName   string
+		totalCount int
+		pages      []int
+		failed     int
+		runs       int
+		success    bool
+		err        error
+	}{
+		{
+			testName:   "failure on second page",
+			totalCount: 150,
+			pages:      []int{100, 50},
+			failed:     120,
+			runs:       150,
+			success:    false,
+		},
+		{
+			testName:   "three successful pages",
+			totalCount: 250,
+			pages:      []int{100, 100, 50},
+			failed:     -1,
+			runs:       250,
+			success:    true,
+		},
+		{
+			testName:   "missing runs",
+			totalCount: 150,
+			pages:      []int{100},
+			failed:     -1,
+			err:        ErrorIncompleteResults,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		var serverURL string
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("per_page") != "100" {
+				t.Errorf("%s: expected per_page=100 but got %q", tc.testName, r.URL.RawQuery)
+			}
+
+			// Serve the requested page, linking to the next one.
+			page := 0
+			fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+			if page == 0 {
+				page = 1
+			}
+			first := 0
+			for _, size := range tc.pages[:page-1] {
+				first += size
+			}
+			if page < len(tc.pages) {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=100&page=%d>; rel="next", <%s%s?per_page=100&page=%d>; rel="last"`,
+					serverURL, r.URL.Path, page+1, serverURL, r.URL.Path, len(tc.pages)))
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunsPage(tc.totalCount, first, tc.pages[page-1], tc.failed)))
+		})
+		serverURL = server.URL
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+		err := repo.MostRecentCommitWasSuccess()
+
+		// Check for the expected error.
+		if !errorMatches(err, tc.err) {
+			t.Errorf("%s: expected error to be %v but got %v", tc.testName, tc.err, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		// Check that every page was collected.
+		if len(repo.RunsResult.CheckRuns) != tc.runs {
+			t.Errorf("%s: expected %d runs but got %d", tc.testName, tc.runs, len(repo.RunsResult.CheckRuns))
+		}
+		if repo.Success != tc.success {
+			t.Errorf("%s: expected success to be %v but got %v", tc.testName, tc.success, repo.Success)
+		}
+	}
+}
+
+func TestCheckRunsPaginationGrowing(t *testing.T) {
+
+	// Each listing has two pages. Runs created while the pages are read
+	// change the total count, and can shift runs between pages.
+	first := mockRunsPage(150, 0, 100, -1)
+	testCases := []struct {
+		testName string
+		pages    []string
+		requests int
+		runs     int
+		err      error
+	}{
+		{
+			testName: "count grows before the last page",
+			pages:    []string{first, mockRunsPage(151, 100, 51, -1)},
+			requests: 2,
+			runs:     151,
+		},
+		{
+			testName: "run shifted to an earlier page",
+			pages:    []string{first, mockRunsPage(151, 101, 50, -1), mockRunsPage(151, 0, 100, -1), mockRunsPage(151, 100, 51, -1)},
+			requests: 4,
+			runs:     151,
+		},
+		{
+			testName: "runs keep shifting",
+			pages:    []string{first, mockRunsPage(151, 101, 50, -1), first, mockRunsPage(152, 102, 50, -1)},
+			requests: 4,
+			err:      ErrorIncompleteResults,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		requests := 0
+		var serverURL string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests%2 == 0 {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=100&page=2>; rel="next"`, serverURL, r.URL.Path))
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(tc.pages[requests]))
+			requests++
+		}))
+		serverURL = server.URL
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+		repo.Sha = "hijklmnop"
+		repo.setRunsURL()
+		err := repo.CheckRuns()
+		if !errorMatches(err, tc.err) {
+			t.Errorf("%s: expected error to be %v but got %v", tc.testName, tc.err, err)
+		}
+		if requests != tc.requests || len(repo.RunsResult.CheckRuns) != tc.runs {
+			t.Errorf("%s: expected %d runs from %d requests but got %d from %d", tc.testName, tc.runs, tc.requests, len(repo.RunsResult.CheckRuns), requests)
+		}
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		link     string
+		expected string
+	}{
+		{link: "", expected: ""},
+		{link: `<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, expected: "https://api.github.com/x?page=2"},
+		{link: `<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`, expected: ""},
+		{link: `<https://api.github.com/x?page=3>; rel="last next"`, expected: "https://api.github.com/x?page=3"},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		resp := &apiResponse{header: http.Header{"Link": {tc.link}}}
+		if got := resp.nextPageURL(); got != tc.expected {
+			t.Errorf("%q: expected next page %q but got %q", tc.link, tc.expected, got)
+		}
+	}
+}
+
+func TestCountedPages(t *testing.T) {
+
+	// Fetch each counted listing for a commit.
+	fetchers := map[string]func(*Repository) error{
+		"check_runs":   (*Repository).CheckRuns,
+		"statuses":     (*Repository).Statuses,
+		"check_suites": (*Repository).CheckSuites,
+	}
+
+	// Setup test cases, where %s is the listing's items key.
+	testCases := []struct {
+		testName string
+		body     string
+		err      error
+	}{
+		{
+			testName: "complete listing",
+			body:     `{"total_count": 0, "%s": []}`,
+		},
+		{
+			testName: "missing items",
+			body:     `{"total_count": 0}`,
+			err:      ErrorDecodeResponse,
+		},
+		{
+			testName: "null items",
+			body:     `{"total_count": 0, "%s": null}`,
+			err:      ErrorDecodeResponse,
+		},
+		{
+			testName: "missing total count",
+			body:     `{"%s": []}`,
+			err:      ErrorDecodeResponse,
+		},
+		{
+			testName: "incomplete listing",
+			body:     `{"total_count": 2, "%s": [{}]}`,
+			err:      ErrorIncompleteResults,
+		},
+	}
+
+	// Iterate over each individual test case (tc), for each listing.
+	for _, tc := range testCases {
+		for key, fetch := range fetchers {
+			body := tc.body
+			if strings.Contains(body, "%s") {
+				body = fmt.Sprintf(body, key)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(body))
+			}))
+			repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+			repo.Sha = "hijklmnop"
+			repo.setRunsURL()
+			err := fetch(repo)
+			server.Close()
+			if !errorMatches(err, tc.err) {
+				t.Errorf("%s: %s: expected error to be %v but got %v", tc.testName, key, tc.err, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package checkgitci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// WithBranchProtection makes a repository require the status checks that
+// GitHub enforces on the protected branch, from its branch protection rule
+// and the repository's rulesets, in addition to any ChecksPolicy. If the
+// token cannot read the protection rule, the rulesets and ChecksPolicy are
+// still used, and the error is stored in the ProtectionErr field.
+func WithBranchProtection() RepositoryOption {
+	return func(r *Repository) {
+		r.UseProtection = true
+	}
+}
+
+// ProtectionError is returned when the token does not have permission to
+// read a branch's protection rule, which needs admin access to the
+// repository. It satisfies errors.Is(err, ErrorProtectionAccess), and
+// unwraps to the APIError.
+type ProtectionError struct {
+	// Branch is the protected branch.
+	Branch string
+
+	// Err is the error from the GitHub API.
+	Err error
+}
+
+// Error returns the error message.
+func (e *ProtectionError) Error() string {
+	return ErrorProtectionAccess.Error() + " on " + e.Branch + ": " + e.Err.Error()
+}
+
+// Is reports whether target is ErrorProtectionAccess.
+func (e *ProtectionError) Is(target error) bool {
+	return target == ErrorProtectionAccess
+}
+
+// Unwrap returns the error from the GitHub API.
+func (e *ProtectionError) Unwrap() error {
+	return e.Err
+}
+
+// RequiredStatusChecks queries the GitHub branch protection and rules API
+// endpoints for the status checks required on the protected branch, and
+// attaches them to the Repository struct RequiredResult field. The
+// protected branch is the Branch field, or the base branch of the pull
+// request being checked, or else the repository's default branch. If the
+// token cannot read the branch protection rule, the checks required by
+// rulesets are still attached, and a *ProtectionError is returned.
+func (r *Repository) RequiredStatusChecks() error {
+	return r.RequiredStatusChecksContext(context.Background())
+}
+
+// RequiredStatusChecksContext is like RequiredStatusChecks, but stops
+// waiting for the GitHub API when ctx is cancelled or its deadline passes.
+func (r *Repository) RequiredStatusChecksContext(ctx context.Context) error {
+	branch, err := r.protectedBranch(ctx)
+	if err != nil {
+		return err
+	}
+	result := RequiredChecksAPI{Branch: branch}
+
+	// Get the checks from the branch protection rule. A branch without
+	// a rule, or with a rule that doesn't require status checks, has no
+	// required checks, but other errors mean the token can't read the
+	// rule.
+	var protectionErr error
+	classic, err := r.protectionChecks(ctx, branch)
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		result.Strict = classic.Strict
+		result.Checks = append(result.Checks, classic.Checks...)
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && isUnprotectedMessage(apiErr.Message):
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusForbidden || apiErr.StatusCode == http.StatusNotFound) && !apiErr.rateLimited:
+		protectionErr = &ProtectionError{Branch: branch, Err: err}
+	default:
+		return err
+	}
+
+	// Get the checks from rulesets. Servers without rulesets, like
+	// older GitHub Enterprise Server versions, respond with 404.
+	rules, err := r.rulesetChecks(ctx, branch)
+	if err != nil && !IsNotFound(err) {
+		return err
+	}
+	result.Strict = result.Strict || rules.Strict
+	result.Checks = append(result.Checks, rules.Checks...)
+
+	// The same check is often required by both.
+	result.Checks = uniqueChecks(result.Checks)
+	r.RequiredResult = result
+	return protectionErr
+}
+
+// isUnprotectedMessage reports whether the message of a 404 response from
+// the branch protection API means the branch has no required status checks,
+// rather than that the token can't read the rule.
+func isUnprotectedMessage(message string) bool {
+	return strings.EqualFold(message, "Branch not protected") ||
+		strings.EqualFold(message, "Required status checks not enabled")
+}
+
+// protectedBranch returns the branch whose protection applies to the
+// commit being checked.
+func (r *Repository) protectedBranch(ctx context.Context) (string, error) {
+	if r.Branch != "" {
+		return r.Branch, nil
+	}
+	if r.PullRequest != nil && r.PullRequest.Head.Sha == r.Sha && r.PullRequest.Base.Ref != "" {
+		return r.PullRequest.Base.Ref, nil
+	}
+
+	// Fall back to the default branch.
+	resp, err := r.client().makeGetRequest(ctx, r.client().repoURL(r.Owner, r.Name))
+	if err != nil {
+		return "", err
+	}
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := resp.decode(&repo); err != nil {
+		return "", err
+	}
+	if repo.DefaultBranch == "" {
+		return "", resp.decodeError(errors.New("repository has no default branch"))
+	}
+	return repo.DefaultBranch, nil
+}
+
+// protectionChecks returns the required checks from a branch's protection
+// rule.
+func (r *Repository) protectionChecks(ctx context.Context, branch string) (RequiredChecksAPI, error) {
+	resp, err := r.client().makeGetRequest(ctx, r.client().protectionURL(r.Owner, r.Name, branch))
+	if err != nil {
+		return RequiredChecksAPI{}, err
+	}
+
+	// Older responses only list contexts, and newer ones also list
+	// the app each check must come from.
+	var body struct {
+		Strict   bool            `json:"strict"`
+		Contexts []string        `json:"contexts"`
+		Checks   []RequiredCheck `json:"checks"`
+	}
+	if err := resp.decode(&body); err != nil {
+		return RequiredChecksAPI{}, err
+	}
+	result := RequiredChecksAPI{Strict: body.Strict, Checks: body.Checks}
+	if len(result.Checks) == 0 {
+		for _, name := range body.Contexts {
+			result.Checks = append(result.Checks, RequiredCheck{Context: name})
+		}
+	}
+	return result, nil
+}
+
+// rulesetChecks returns the required checks from the ruleset rules that
+// apply to a branch.
+func (r *Repository) rulesetChecks(ctx context.Context, branch string) (RequiredChecksAPI, error) {
+	rulesURL := addQuery(r.client().rulesURL(r.Owner, r.Name, branch), url.Values{"per_page": {strconv.Itoa(perPage)}})
+	var result RequiredChecksAPI
+	err := r.client().forEachPage(ctx, rulesURL, func(resp *apiResponse) error {
+		var rules []struct {
+			Type       string `json:"type"`
+			Parameters struct {
+				Strict bool `json:"strict_required_status_checks_policy"`
+				Checks []struct {
+					Context       string `json:"context"`
+					IntegrationID int64  `json:"integration_id"`
+				} `json:"required_status_checks"`
+			} `json:"parameters"`
+		}
+		if err := resp.decode(&rules); err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			if rule.Type != "required_status_checks" {
+				continue
+			}
+			result.Strict = result.Strict || rule.Parameters.Strict
+			for _, check := range rule.Parameters.Checks {
+				result.Checks = append(result.Checks, RequiredCheck{Context: check.Context, AppID: check.IntegrationID})
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// uniqueChecks returns checks without duplicates, in order.
+func uniqueChecks(checks []RequiredCheck) []RequiredCheck {
+	var unique []RequiredCheck
+	seen := map[RequiredCheck]bool{}
+	for _, check := range checks {
+		if !seen[check] {
+			seen[check] = true
+			unique = append(unique, check)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,157 @@
+package checkgitci
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBranchProtection(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName         string
+		protectionStatus int
+		protection       string
+		rulesStatus      int
+		rules            string
+		verdict          Verdict
+		required         int
+		protectionErr    bool
+	}{
+		{
+			testName:         "protection rule and ruleset",
+			protectionStatus: http.StatusOK,
+			protection:       `{"strict": true, "contexts": ["test (ubuntu)"], "checks": [{"context": "test (ubuntu)", "app_id": null}]}`,
+			rulesStatus:      http.StatusOK,
+			rules:            `[{"type": "deletion"}, {"type": "required_status_checks", "parameters": {"required_status_checks": [{"context": "test (windows)"}, {"context": "test (ubuntu)"}]}}]`,
+			verdict:          VerdictPassed,
+			required:         2,
+		},
+		{
+			testName:         "protection rule with only contexts",
+			protectionStatus: http.StatusOK,
+			protection:       `{"strict": false, "contexts": ["test (ubuntu)", "lint"]}`,
+			rulesStatus:      http.StatusOK,
+			rules:            `[]`,
+			verdict:          VerdictPending,
+			required:         2,
+		},
+		{
+			testName:         "required check from another app",
+			protectionStatus: http.StatusOK,
+			protection:       `{"checks": [{"context": "test (ubuntu)", "app_id": 15368}]}`,
+			rulesStatus:      http.StatusOK,
+			rules:            `[]`,
+			verdict:          VerdictPending,
+			required:         1,
+		},
+		{
+			testName:         "branch not protected",
+			protectionStatus: http.StatusNotFound,
+			protection:       `{"message": "Branch not protected"}`,
+			rulesStatus:      http.StatusOK,
+			rules:            `[]`,
+			verdict:          VerdictPending,
+		},
+		{
+			testName:         "required status checks not enabled",
+			protectionStatus: http.StatusNotFound,
+			protection:       `{"message": "Required status checks not enabled"}`,
+			rulesStatus:      http.StatusOK,
+			rules:            `[]`,
+			verdict:          VerdictPending,
+		},
+		{
+			testName:         "no permission falls back to rulesets",
+			protectionStatus: http.StatusForbidden,
+			protection:       `{"message": "Resource not accessible by integration"}`,
+			rulesStatus:      http.StatusOK,
+			rules:            `[{"type": "required_status_checks", "parameters": {"required_status_checks": [{"context": "test (windows)"}]}}]`,
+			verdict:          VerdictPassed,
+			required:         1,
+			protectionErr:    true,
+		},
+		{
+			testName:         "server without rulesets",
+			protectionStatus: http.StatusOK,
+			protection:       `{"contexts": ["test (ubuntu)"]}`,
+			rulesStatus:      http.StatusNotFound,
+			rules:            `{"message": "Not Found"}`,
+			verdict:          VerdictPassed,
+			required:         1,
+		},
+		{
+			testName:         "protection API error",
+			protectionStatus: http.StatusInternalServerError,
+			protection:       `{"message": "Server Error"}`,
+			rulesStatus:      http.StatusOK,
+			rules:            `[]`,
+			verdict:          VerdictError,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/repos/facebook/react":
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"default_branch": "main"}`))
+			case r.URL.Path == "/repos/facebook/react/branches/main/protection/required_status_checks":
+				w.WriteHeader(tc.protectionStatus)
+				w.Write([]byte(tc.protection))
+			case r.URL.Path == "/repos/facebook/react/rules/branches/main":
+				w.WriteHeader(tc.rulesStatus)
+				w.Write([]byte(tc.rules))
+			case strings.HasSuffix(r.URL.Path, "/commits"):
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(mockCommitsAPI1))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockRunsAPIOptional))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), WithBranchProtection())
+		eval := repo.EvaluateMostRecentCommit()
+		if eval.Verdict != tc.verdict {
+			t.Errorf("%s: expected verdict %q but got %q (%s)", tc.testName, tc.verdict, eval.Verdict, eval.Reason)
+		}
+		if tc.verdict == VerdictError {
+			continue
+		}
+		if len(repo.RequiredResult.Checks) != tc.required || repo.RequiredResult.Branch != "main" {
+			t.Errorf("%s: expected %d required checks on main but got %+v", tc.testName, tc.required, repo.RequiredResult)
+		}
+		if errors.Is(repo.ProtectionErr, ErrorProtectionAccess) != tc.protectionErr {
+			t.Errorf("%s: unexpected protection error: %v", tc.testName, repo.ProtectionErr)
+		}
+	}
+}
+
+func TestProtectedBranch(t *testing.T) {
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		// Branch names with slashes are escaped.
+		if r.URL.EscapedPath() != "/repos/facebook/react/branches/release%2F1.0/protection/required_status_checks" {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"contexts": ["build"]}`))
+	}, nil)
+	defer server.Close()
+
+	repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), ForBranch("release/1.0"))
+	if err := repo.RequiredStatusChecks(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.RequiredResult.Checks) != 1 || repo.RequiredResult.Checks[0].Context != "build" {
+		t.Errorf("unexpected required checks: %+v", repo.RequiredResult)
+	}
+}
@@ -0,0 +1,83 @@
+package checkgitci
+
+import (
+	"context"
+)
+
+// IsFork reports whether the pull request's head branch is in a different
+// repository than its base branch, including a fork that has since been
+// deleted.
+func (p *PullRequestAPI) IsFork() bool {
+	if p.Head.Repo == nil || p.Base.Repo == nil {
+		return true
+	}
+	return p.Head.Repo.FullName != p.Base.Repo.FullName
+}
+
+// GetPullRequest queries the GitHub pulls API endpoint for a pull request
+// by number, stores it in the PullRequest field of a Repository struct,
+// and stores its head commit in the Sha field. The HeadChanged field is
+// set to true if the same pull request was fetched before, and its head
+// commit has changed since. This function returns an error or nil if no
+// error.
+func (r *Repository) GetPullRequest(number int) error {
+	return r.GetPullRequestContext(context.Background(), number)
+}
+
+// GetPullRequestContext is like GetPullRequest, but stops waiting for the
+// GitHub API when ctx is cancelled or its deadline passes.
+func (r *Repository) GetPullRequestContext(ctx context.Context, number int) error {
+
+	// Throw errors if no owner/name/number.
+	if err := r.validate(); err != nil {
+		return err
+	}
+	if number <= 0 {
+		return ErrorInvalidPullRequest
+	}
+
+	// Make the GET request.
+	resp, err := r.client().makeGetRequest(ctx, r.client().pullURL(r.Owner, r.Name, number))
+	if err != nil {
+		return err
+	}
+
+	// Unmarshall into the response object.
+	var pr PullRequestAPI
+	if err := resp.decode(&pr); err != nil {
+		return err
+	}
+	if pr.Head.Sha == "" {
+		return resp.decodeError(ErrorInvalidPullRequest)
+	}
+
+	// Compare with the last time this pull request was fetched.
+	previous := r.PullRequest
+	r.HeadChanged = previous != nil && previous.Number == pr.Number && previous.Head.Sha != pr.Head.Sha
+	r.PullRequest = &pr
+
+	// Check runs for pull requests, including those from forks, are
+	// reported on the base repository, so the runs url uses this
+	// repository with the head commit.
+	r.Sha = pr.Head.Sha
+	r.setRunsURL()
+	return nil
+}
+
+// CheckPullRequest fetches a pull request by number (see GetPullRequest),
+// and then checks the GitHub CI runs for its head commit like
+// MostRecentCommitWasSuccess, storing the results on the repository
+// Success, Completed and Failed fields. This function returns an error or
+// nil if no error.
+func (r *Repository) CheckPullRequest(number int) error {
+	return r.CheckPullRequestContext(context.Background(), number)
+}
+
+// CheckPullRequestContext is like CheckPullRequest, but stops waiting for
+// the GitHub API when ctx is cancelled or its deadline passes.
+func (r *Repository) CheckPullRequestContext(ctx context.Context, number int) error {
+	if err := r.GetPullRequestContext(ctx, number); err != nil {
+		return err
+	}
+	return r.checkCommit(ctx)
+}
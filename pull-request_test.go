@@ -0,0 +1,82 @@
+package checkgitci
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// mockPullRequest returns pulls API JSON for a pull request with the given
+// head commit and head repository.
+func mockPullRequest(headSha, headRepo, mergeable string) string {
+	return fmt.Sprintf(`{
+		"number": 123,
+		"state": "open",
+		"mergeable": %s,
+		"mergeable_state": "clean",
+		"head": {"ref": "feature", "sha": %q, "repo": {"full_name": %q}},
+		"base": {"ref": "main", "sha": "basesha", "repo": {"full_name": "facebook/react"}}
+	}`, mergeable, headSha, headRepo)
+}
+
+func TestCheckPullRequest(t *testing.T) {
+
+	// The pull request's head moves after the first check.
+	heads := []string{mockPullRequest("forkhead1", "octocat/react", "null"), mockPullRequest("forkhead2", "octocat/react", "true")}
+	requests := 0
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/facebook/react/pulls/123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(heads[requests]))
+		requests++
+	}, func(w http.ResponseWriter, r *http.Request) {
+		// Runs for fork pull requests are on the base repository.
+		if !strings.HasPrefix(r.URL.Path, "/repos/facebook/react/commits/forkhead") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if strings.Contains(r.URL.Path, "forkhead1") {
+			w.Write([]byte(mockRunsAPI3))
+			return
+		}
+		w.Write([]byte(mockRunsAPI1))
+	})
+	defer server.Close()
+
+	repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+
+	// The first check sees pending runs on the first head.
+	if err := repo.CheckPullRequest(123); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Sha != "forkhead1" || repo.Completed || repo.HeadChanged {
+		t.Errorf("unexpected first check: sha %q, completed %v, head changed %v", repo.Sha, repo.Completed, repo.HeadChanged)
+	}
+	if !repo.PullRequest.IsFork() || repo.PullRequest.Mergeable != nil {
+		t.Errorf("expected a fork with unknown mergeability but got %+v", repo.PullRequest)
+	}
+
+	// The second check sees passing runs on the new head.
+	if err := repo.CheckPullRequest(123); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Sha != "forkhead2" || !repo.Completed || !repo.Success || !repo.HeadChanged {
+		t.Errorf("unexpected second check: sha %q, success %v, head changed %v", repo.Sha, repo.Success, repo.HeadChanged)
+	}
+	if repo.PullRequest.Mergeable == nil || !*repo.PullRequest.Mergeable || repo.PullRequest.MergeableState != "clean" {
+		t.Errorf("expected a mergeable pull request but got %+v", repo.PullRequest)
+	}
+
+	// Invalid and missing pull requests return errors.
+	if err := repo.CheckPullRequest(0); err != ErrorInvalidPullRequest {
+		t.Errorf("expected error to be %v but got %v", ErrorInvalidPullRequest, err)
+	}
+	if err := repo.CheckPullRequest(456); !IsNotFound(err) {
+		t.Errorf("expected a not found error but got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package checkgitci
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GitHub asks clients to wait at least a minute after hitting a secondary
+// rate limit that does not say how long to wait.
+const secondaryRateLimitWait = time.Minute
+
+// Extra time waited past a rate limit reset, to allow for clock drift.
+const rateLimitResetSlack = time.Second
+
+// RateLimit holds the rate limit information from the most recent GitHub
+// API response.
+type RateLimit struct {
+	// Limit is the maximum number of requests allowed per hour.
+	Limit int
+
+	// Remaining is the number of requests remaining in the current window.
+	Remaining int
+
+	// Used is the number of requests made in the current window.
+	Used int
+
+	// Reset is when the current window ends.
+	Reset time.Time
+
+	// Resource is the rate limit resource the request counted against,
+	// such as "core" or "search".
+	Resource string
+
+	// RetryAfter is how long GitHub asked clients to wait before
+	// retrying, after a secondary rate limit was hit.
+	RetryAfter time.Duration
+}
+
+// parseRateLimit parses the rate limit headers from a GitHub API response.
+// It returns false if the response has no rate limit headers.
+func parseRateLimit(header http.Header) (RateLimit, bool) {
+	var rl RateLimit
+	found := false
+
+	// Parse integer headers, ignoring any that are missing or invalid.
+	parseInt := func(key string, value *int) {
+		if n, err := strconv.Atoi(header.Get(key)); err == nil {
+			*value = n
+			found = true
+		}
+	}
+	parseInt("X-RateLimit-Limit", &rl.Limit)
+	parseInt("X-RateLimit-Remaining", &rl.Remaining)
+	parseInt("X-RateLimit-Used", &rl.Used)
+
+	// The reset header is in seconds since the epoch.
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(reset, 0)
+		found = true
+	}
+	if resource := header.Get("X-RateLimit-Resource"); resource != "" {
+		rl.Resource = resource
+		found = true
+	}
+
+	// Retry-After is in seconds.
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds >= 0 {
+		rl.RetryAfter = time.Duration(seconds) * time.Second
+		found = true
+	}
+	return rl, found
+}
+
+// WithRateLimitWait makes the client wait, instead of returning an error,
+// when a request is rejected because of a primary or secondary rate limit.
+// The client waits until the limit resets (or for as long as GitHub asks),
+// and then retries the request. Waits longer than maxWait, or that would
+// outlast the request's context, return the rate limit error instead. A
+// maxWait of zero or less allows waits of any length.
+func WithRateLimitWait(maxWait time.Duration) ClientOption {
+	return func(c *Client) error {
+		c.waitForRateLimit = true
+		c.maxRateLimitWait = maxWait
+		return nil
+	}
+}
+
+// RateLimit returns the rate limit information from the most recent
+// response that included it.
+func (c *Client) RateLimit() RateLimit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rateLimit
+}
+
+// setRateLimit records the rate limit information from a response.
+func (c *Client) setRateLimit(rl RateLimit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimit = rl
+}
+
+// rateLimitWait returns how long to wait before retrying a request that was
+// rejected because of a rate limit, and whether the client should wait.
+func (c *Client) rateLimitWait(ctx context.Context, apiErr *APIError) (time.Duration, bool) {
+	if !c.waitForRateLimit || !apiErr.rateLimited {
+		return 0, false
+	}
+
+	// Prefer Retry-After, then the reset time for primary rate
+	// limits, then GitHub's advice for secondary rate limits.
+	now := c.now()
+	wait := secondaryRateLimitWait
+	switch {
+	case apiErr.RateLimit.RetryAfter > 0:
+		wait = apiErr.RateLimit.RetryAfter
+	case apiErr.RateLimit.Remaining == 0 && !apiErr.RateLimit.Reset.IsZero():
+		wait = apiErr.RateLimit.Reset.Sub(now) + rateLimitResetSlack
+		if wait < rateLimitResetSlack {
+			wait = rateLimitResetSlack
+		}
+	}
+
+	// Don't wait longer than allowed, or past the context deadline.
+	if c.maxRateLimitWait > 0 && wait > c.maxRateLimitWait {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(wait)) {
+		return 0, false
+	}
+	return wait, true
+}
+
+// sleepContext waits for d, or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return contextError(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+package checkgitci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newRateLimitedServer returns a server that rejects the first request
+// with the given status and headers, and then responds with commits.
+func newRateLimitedServer(status int, header map[string]string, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if *requests == 1 {
+			for key, value := range header {
+				w.Header().Set(key, value)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Used", "1")
+		w.Header().Set("X-RateLimit-Resource", "core")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}))
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	reset := strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		status   int
+		header   map[string]string
+		maxWait  time.Duration
+		wait     bool
+		expected time.Duration
+	}{
+		{
+			testName: "no waiting by default",
+			status:   http.StatusForbidden,
+			header:   map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+		},
+		{
+			testName: "primary rate limit waits for reset",
+			status:   http.StatusForbidden,
+			header:   map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			wait:     true,
+			expected: 30*time.Second + rateLimitResetSlack,
+		},
+		{
+			testName: "secondary rate limit waits for retry-after",
+			status:   http.StatusTooManyRequests,
+			header:   map[string]string{"Retry-After": "5"},
+			wait:     true,
+			expected: 5 * time.Second,
+		},
+		{
+			testName: "secondary rate limit without retry-after",
+			status:   http.StatusForbidden,
+			header:   map[string]string{},
+			wait:     true,
+			expected: secondaryRateLimitWait,
+		},
+		{
+			testName: "wait longer than allowed",
+			status:   http.StatusForbidden,
+			header:   map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			wait:     true,
+			maxWait:  10 * time.Second,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		requests := 0
+		server := newRateLimitedServer(tc.status, tc.header, &requests)
+		defer server.Close()
+
+		// Record waits instead of sleeping.
+		var opts []ClientOption
+		if tc.wait {
+			opts = append(opts, WithRateLimitWait(tc.maxWait))
+		}
+		client := newTestClient(t, server, opts...)
+		client.now = func() time.Time { return now }
+		var waited time.Duration
+		client.sleep = func(ctx context.Context, d time.Duration) error {
+			waited += d
+			return nil
+		}
+
+		repo := NewRepository("facebook", "react", WithClient(client))
+		err := repo.GetMostRecentCommit()
+
+		// Check that the request only succeeded if the client waited.
+		if tc.expected > 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.testName, err)
+			}
+		} else if !IsRateLimited(err) {
+			t.Errorf("%s: expected a rate limit error but got %v", tc.testName, err)
+		}
+
+		// Check how long the client waited.
+		if waited != tc.expected {
+			t.Errorf("%s: expected to wait %v but waited %v", tc.testName, tc.expected, waited)
+		}
+	}
+}
+
+func TestRateLimitAccessor(t *testing.T) {
+	requests := 0
+	server := newRateLimitedServer(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, &requests)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	repo := NewRepository("facebook", "react", WithClient(client))
+
+	// The rate limit should be recorded from failed responses...
+	if err := repo.GetMostRecentCommit(); !IsRateLimited(err) {
+		t.Fatalf("expected a rate limit error but got %v", err)
+	}
+	if rl := client.RateLimit(); rl.Remaining != 0 {
+		t.Errorf("expected no remaining requests but got %+v", rl)
+	}
+
+	// ...and from successful ones.
+	if err := repo.GetMostRecentCommit(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rl := client.RateLimit()
+	if rl.Limit != 5000 || rl.Remaining != 4999 || rl.Used != 1 || rl.Resource != "core" {
+		t.Errorf("unexpected rate limit %+v", rl)
+	}
+}
+
+func TestRateLimitWaitRespectsContext(t *testing.T) {
+	requests := 0
+	server := newRateLimitedServer(http.StatusTooManyRequests, map[string]string{"Retry-After": "60"}, &requests)
+	defer server.Close()
+
+	// A wait that would outlast the context deadline returns the error.
+	client := newTestClient(t, server, WithRateLimitWait(0))
+	repo := NewRepository("facebook", "react", WithClient(client))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := repo.GetMostRecentCommitContext(ctx); !IsRateLimited(err) {
+		t.Errorf("expected a rate limit error but got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request but got %d", requests)
+	}
+}
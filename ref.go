@@ -0,0 +1,68 @@
+package checkgitci
+
+import (
+	"context"
+	"strings"
+)
+
+// ResolveRef finds the commit that ref points to, and stores it in the
+// Sha field of a Repository struct. The ref can be a branch or tag name
+// (annotated tags are followed to their commit), a full or abbreviated
+// commit Sha, or a fully qualified ref like "refs/tags/v1.4.2". This
+// function returns an error or nil if no error.
+func (r *Repository) ResolveRef(ref string) error {
+	return r.ResolveRefContext(context.Background(), ref)
+}
+
+// ResolveRefContext is like ResolveRef, but stops waiting for the GitHub
+// API when ctx is cancelled or its deadline passes.
+func (r *Repository) ResolveRefContext(ctx context.Context, ref string) error {
+
+	// Throw errors if no owner/name/ref.
+	if err := r.validate(); err != nil {
+		return err
+	}
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "refs/")
+	if ref == "" {
+		return ErrorNoRef
+	}
+
+	// Make the GET request. The single commit endpoint accepts
+	// branches, tags and abbreviated Shas, as well as
+	// "heads/..." and "tags/..." refs.
+	resp, err := r.client().makeGetRequest(ctx, r.client().commitURL(r.Owner, r.Name, ref))
+	if err != nil {
+		return err
+	}
+
+	// Unmarshall into the response object.
+	var commit CommitAPI
+	if err := resp.decode(&commit); err != nil {
+		return err
+	}
+	if commit.Sha == "" {
+		return resp.decodeError(ErrorNoRef)
+	}
+
+	// Set the commit, and the check runs url.
+	r.Sha = commit.Sha
+	r.setRunsURL()
+	return nil
+}
+
+// CheckRef resolves ref to a commit (see ResolveRef), and then checks the
+// GitHub CI runs for that commit like MostRecentCommitWasSuccess, storing
+// the results on the repository Success, Completed and Failed fields.
+// This function returns an error or nil if no error.
+func (r *Repository) CheckRef(ref string) error {
+	return r.CheckRefContext(context.Background(), ref)
+}
+
+// CheckRefContext is like CheckRef, but stops waiting for the GitHub API
+// when ctx is cancelled or its deadline passes.
+func (r *Repository) CheckRefContext(ctx context.Context, ref string) error {
+	if err := r.ResolveRefContext(ctx, ref); err != nil {
+		return err
+	}
+	return r.checkCommit(ctx)
+}
@@ -0,0 +1,78 @@
+package checkgitci
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckRef(t *testing.T) {
+
+	// The commits server stands in for the single commit endpoint,
+	// which resolves tags (annotated or not) and short Shas.
+	commits := map[string]string{
+		"/repos/facebook/react/commits/v1.4.2":      "tagged1234",
+		"/repos/facebook/react/commits/tags/v1.4.2": "tagged1234",
+		"/repos/facebook/react/commits/abc123":      "abc123def456",
+		"/repos/facebook/react/commits/heads/main":  "mainhead99",
+	}
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		sha, ok := commits[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "No commit found for SHA: nope"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"sha": "` + sha + `"}`))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if strings.Contains(r.URL.Path, "/abc123def456/") {
+			w.Write([]byte(mockRunsAPI2))
+			return
+		}
+		w.Write([]byte(mockRunsAPI1))
+	})
+	defer server.Close()
+
+	// Setup test cases.
+	testCases := []struct {
+		ref      string
+		sha      string
+		success  bool
+		notFound bool
+		err      error
+	}{
+		{ref: "v1.4.2", sha: "tagged1234", success: true},
+		{ref: "refs/tags/v1.4.2", sha: "tagged1234", success: true},
+		{ref: "refs/heads/main", sha: "mainhead99", success: true},
+		{ref: "abc123", sha: "abc123def456", success: false},
+		{ref: "nope", notFound: true, err: ErrorFailedAPICall},
+		{ref: " ", err: ErrorNoRef},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+		err := repo.CheckRef(tc.ref)
+
+		// Check for the expected error.
+		if !errorMatches(err, tc.err) || IsNotFound(err) != tc.notFound {
+			t.Errorf("%q: expected error to be %v but got %v", tc.ref, tc.err, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		// Check the resolved commit and its runs.
+		if repo.Sha != tc.sha {
+			t.Errorf("%q: expected sha to be %q but got %q", tc.ref, tc.sha, repo.Sha)
+		}
+		if !strings.HasSuffix(repo.RunsURL, "/commits/"+tc.sha+"/check-runs") {
+			t.Errorf("%q: unexpected runs url %q", tc.ref, repo.RunsURL)
+		}
+		if repo.Success != tc.success || !repo.Completed || !repo.HasCheckRuns {
+			t.Errorf("%q: expected success to be %v but got %v", tc.ref, tc.success, repo.Success)
+		}
+	}
+}
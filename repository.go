@@ -1,99 +1,98 @@
 package checkgitci
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"errors"
+	"net/url"
+	"strconv"
 )
 
-// Base URL for GitHub API
-const baseURL = "https://api.github.com"
-
-// commitsURL takes a repository owner and name, and returns the url to the
-// GitHub API for viewing commmits.
-func commitsURL(owner, name string) string {
-	return fmt.Sprintf("%s/repos/%s/%s/commits", baseURL, owner, name)
-}
-
-// setRunsURL sets the GitHub API url on a repository for the check-runs API endpoint.
-func (r *Repository) setRunsURL() {
-	r.RunsURL = fmt.Sprintf("%s/repos/%s/%s/commits/%s/check-runs", baseURL, r.Owner, r.Name, r.Sha)
+// RepositoryOption configures a Repository created by NewRepository.
+type RepositoryOption func(*Repository)
 
+// WithClient sets the Client a repository uses to make GitHub API
+// requests. Repositories without a Client use a default client for
+// api.github.com.
+func WithClient(c *Client) RepositoryOption {
+	return func(r *Repository) {
+		r.Client = c
+	}
 }
 
-// NewRepository takes an owner and name as string fields, and returns
-// a pointer to a Repository. It automatically uses the owner and name fields
-// to set the CommitsURL field.
-func NewRepository(owner, name string) *Repository {
-	return &Repository{
-		Owner:      owner,
-		Name:       name,
-		CommitsURL: commitsURL(owner, name),
+// ForBranch makes a repository check the head of branch, instead of the
+// head of the default branch.
+func ForBranch(branch string) RepositoryOption {
+	return func(r *Repository) {
+		r.Branch = branch
 	}
 }
 
-// makeGetRequest helps make get requests. It takes a url, and
-// returns a slice of bytes and an error (or nil if no error).
-func makeGetRequest(url string) ([]byte, error) {
-
-	// Get http request.
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// client returns the Client used by a repository, falling back to the
+// default client if none was set.
+func (r *Repository) client() *Client {
+	if r.Client == nil {
+		return defaultClient
 	}
+	return r.Client
+}
 
-	// Add headers.
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("Content-Type", "application/json")
-
-	// Make request.
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// setRunsURL sets the GitHub API url on a repository for the check-runs API endpoint.
+func (r *Repository) setRunsURL() {
+	r.RunsURL = r.client().runsURL(r.Owner, r.Name, r.Sha)
+}
 
-	// Check that the response was ok.
-	if resp.StatusCode != http.StatusOK {
-		// TODO: Consider giving more informative error.
-		return nil, ErrorFailedAPICall
+// NewRepository takes an owner and name as string fields, and returns
+// a pointer to a Repository. It automatically uses the owner and name fields
+// to set the CommitsURL field. Optional arguments (like WithClient)
+// further configure the repository.
+func NewRepository(owner, name string, opts ...RepositoryOption) *Repository {
+	r := &Repository{
+		Owner: owner,
+		Name:  name,
 	}
-
-	// Read response body into slice of bytes.
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, ErrorIOReadAll
+	for _, opt := range opts {
+		opt(r)
 	}
-	return bodyBytes, nil
+	r.CommitsURL = r.client().commitsURL(owner, name)
+	return r
 }
 
 // GetMostRecentCommit queries the GitHub commits API endpoint,
 // finds the Sha hash for the most recent Git commit in a repository,
-// and stores it in the Sha field of a Repository struct.
-// This function returns an error or nil if no error. This function also
-// takes optional arguments (for example to override the url for the
-// GitHub commits API), but these are mostly for testing.
-func (r *Repository) GetMostRecentCommit(params ...getMostRecentCommitArgs) error {
+// and stores it in the Sha field of a Repository struct. If the
+// Branch field is set, the most recent commit on that branch is used
+// instead of the most recent commit on the default branch.
+// This function returns an error or nil if no error.
+func (r *Repository) GetMostRecentCommit() error {
+	return r.GetMostRecentCommitContext(context.Background())
+}
+
+// GetMostRecentCommitContext is like GetMostRecentCommit, but stops
+// waiting for the GitHub API when ctx is cancelled or its deadline passes.
+func (r *Repository) GetMostRecentCommitContext(ctx context.Context) error {
 	// Get commits API url.
-	url := r.CommitsURL
+	commitsURL := r.CommitsURL
+	if commitsURL == "" {
+		commitsURL = r.client().commitsURL(r.Owner, r.Name)
+	}
 
-	// If parameters are provided, then try to override the default url.
-	// This should be for testing purposes only.
-	if len(params) > 0 {
-		url = params[0].url
+	// Ask for the head of the branch, if one was given.
+	if r.Branch != "" {
+		commitsURL = addQuery(commitsURL, url.Values{"sha": {r.Branch}})
 	}
 
 	// Make the GET request.
-	bodyBytes, err := makeGetRequest(url)
+	resp, err := r.client().makeGetRequest(ctx, commitsURL)
 	if err != nil {
 		return err
 	}
 
 	// Unmarshall into the response object.
 	var responseObject CommitsAPI
-	json.Unmarshal(bodyBytes, &responseObject)
+	if err := resp.decode(&responseObject); err != nil {
+		return err
+	}
 
 	// If the slice is empty, just return nil without setting
 	// most recent commit.
@@ -101,6 +100,12 @@ func (r *Repository) GetMostRecentCommit(params ...getMostRecentCommitArgs) erro
 		return nil
 	}
 
+	// A commit without a Sha means the response was not
+	// really from the commits API.
+	if responseObject[0].Sha == "" {
+		return resp.decodeError(errors.New("commit has no sha"))
+	}
+
 	// Set last commit.
 	r.Sha = responseObject[0].Sha
 
@@ -113,29 +118,39 @@ func (r *Repository) GetMostRecentCommit(params ...getMostRecentCommitArgs) erro
 // CheckRuns queries the GitHub check-runs API endpoint for workflows,
 // and attaches select JSON to the Repository struct RunsResult field.
 // CheckRuns returns an error or nil if no error.
-// This function also takes optional arguments (for example to override
-// the url for the GitHub workflows API), but these are mostly for testing.
-func (r *Repository) CheckRuns(params ...checkRunsArgs) error {
+func (r *Repository) CheckRuns() error {
+	return r.CheckRunsContext(context.Background())
+}
 
-	// Override the url if user supplies one (like in testing).
-	url := r.RunsURL
-	if len(params) > 0 {
-		url = params[0].url
-	}
+// CheckRunsContext is like CheckRuns, but stops waiting for the GitHub
+// API when ctx is cancelled or its deadline passes.
+func (r *Repository) CheckRunsContext(ctx context.Context) error {
 
 	// TODO: Check url is not blank if user is calling this function
 	// independently.
-
-	// Make the request.
-	bodyBytes, err := makeGetRequest(url)
+	runsURL := addQuery(r.RunsURL, url.Values{"per_page": {strconv.Itoa(perPage)}})
+	runsURL = addQuery(runsURL, r.RunsFilter.query())
+
+	// Make the requests, following each page of runs.
+	var result CheckRunsAPI
+	total, err := r.client().forEachCountedPage(ctx, runsURL, "check_runs", func(resp *apiResponse, items json.RawMessage) error {
+		var runs []Run
+		if err := resp.decodeItems(items, &runs); err != nil {
+			return err
+		}
+		for _, run := range runs {
+			run.Origin = OriginCheckRun
+			result.CheckRuns = append(result.CheckRuns, run)
+		}
+		return nil
+	})
 
 	// Check for error.
 	if err != nil {
 		return err
 	}
-
-	// Unmarshall into the RunsResult field.
-	json.Unmarshal(bodyBytes, &r.RunsResult)
+	result.TotalCount = total
+	r.RunsResult = result
 
 	// Check if there are runs...
 	if r.RunsResult.TotalCount == 0 {
@@ -153,12 +168,16 @@ func (r *Repository) CheckRuns(params ...checkRunsArgs) error {
 	return nil
 }
 
-// RunsAreSuccessful iterates over a repository's CI runs, and
+// RunsAreSuccessful iterates over a repository's CI runs (including
+// commit statuses, if the repository uses them), and
 // sets the repository's "Success" field as either true or false.
 // The Success field will be set to false if there are no
-// runs (in CheckRuns function), or if some runs were not successful.
-// The Success field will be true if there are runs, and they are
-// all marked as "success" or "skipped".
+// runs (in CheckRuns function), if every run is ignored, or if some runs
+// were not successful.
+// The Success field will be true if there are runs, and none of them
+// fail under the repository's conclusion policy. By default, that means
+// all runs are marked as "success" or "skipped". With a ChecksPolicy, only
+// the required runs count, and each required check must have a run.
 func (r *Repository) RunsAreSuccessful() {
 
 	// If there are no runs, then return early.
@@ -166,35 +185,64 @@ func (r *Repository) RunsAreSuccessful() {
 		return
 	}
 
+	// Required checks without runs, or an invalid checks policy,
+	// mean the commit was not successful.
+	results, missing, err := r.runResults()
+	if err != nil || len(missing) > 0 {
+		r.Success = false
+		return
+	}
+
 	// Iterate over runs.
-	for _, run := range r.RunsResult.CheckRuns {
-		// If current run is neither successful nor skipped, then
-		// return early.
-		if run.Conclusion != "success" && run.Conclusion != "skipped" {
+	passed := false
+	for _, result := range results {
+		// If current run fails under the policy, or has not
+		// finished, then return early.
+		if result.Outcome == OutcomeFail || result.Outcome == OutcomePending {
 			r.Success = false
 			return
 		}
+		if result.Outcome == OutcomePass {
+			passed = true
+		}
 	}
-	// If we made it this far, runs were successful.
-	r.Success = true
+	// If we made it this far, runs were successful, as long as
+	// at least one of them counted.
+	r.Success = passed
 
 }
 
 // RunsAreComplete sets the repository "Completed" field to true
 // if all the CI runs for the last commit are complete.
 // This function sets the Completed state to false if some runs
-// are still pending.
+// are still pending, or if a check suite (when the repository uses
+// them) has not created its runs yet. With a ChecksPolicy, runs that
+// don't count are not waited for, and required checks without runs are
+// waited for unless the policy counts them as failures.
 func (r *Repository) RunsAreComplete() {
+	// A suite without runs yet means runs are still to come.
+	if r.hasWaitingSuites() {
+		r.Completed = false
+		return
+	}
+
+	// Required checks without runs may still be to come.
+	results, missing, err := r.runResults()
+	if err == nil && len(missing) > 0 && r.missingIsPending() {
+		r.Completed = false
+		return
+	}
+
 	// If there are no runs, return early.
 	if !r.HasCheckRuns {
 		return
 	}
 
 	// Iterate over runs.
-	for _, run := range r.RunsResult.CheckRuns {
+	for _, result := range results {
 		// If current run is not complete,
 		// return early.
-		if run.Status != "completed" {
+		if result.Outcome == OutcomePending {
 			r.Completed = false
 			return
 		}
@@ -204,53 +252,130 @@ func (r *Repository) RunsAreComplete() {
 	r.Completed = true
 }
 
+// RunsHaveFailed sets the repository "Failed" field to true if the last
+// commit can no longer be successful, even if some runs are still
+// pending. That is the case once a run that counts has completed and
+// failed under the repository's conclusion policy, or when a required
+// check without runs counts as a failure. Unlike Success, Failed can be
+// relied on before Completed is true.
+func (r *Repository) RunsHaveFailed() {
+	results, missing, err := r.runResults()
+	if err != nil {
+		r.Failed = false
+		return
+	}
+	if len(missing) > 0 && !r.missingIsPending() {
+		r.Failed = true
+		return
+	}
+	for _, result := range results {
+		if result.Outcome == OutcomeFail {
+			r.Failed = true
+			return
+		}
+	}
+	r.Failed = false
+}
+
 // MostRecentCommitWasSuccess makes API calls to get the most recent commit
 // and GitHub CI runs associated with that commit. This function then checks
-// if last commit was successful, and if the runs were all completed. This
-// function stores the results of these checks on the repository
-// Success and Completed fields. This function will return an error (or
-// nil if there is not an error).
-func (r *Repository) MostRecentCommitWasSuccess(params ...mostRecentCommitArgs) error {
+// if last commit was successful, if the runs were all completed, and if
+// the commit has already failed. This function stores the results of these
+// checks on the repository Success, Completed and Failed fields. If the
+// Branch field is set, the most recent commit on that branch is checked.
+// This function will return an error (or nil if there is not an error).
+func (r *Repository) MostRecentCommitWasSuccess() error {
+	return r.MostRecentCommitWasSuccessContext(context.Background())
+}
+
+// MostRecentCommitWasSuccessContext is like MostRecentCommitWasSuccess,
+// but stops waiting for the GitHub API when ctx is cancelled or its
+// deadline passes.
+func (r *Repository) MostRecentCommitWasSuccessContext(ctx context.Context) error {
 
 	// Throw errors if no owner/name.
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	// Get the most recent commit.
+	err := r.GetMostRecentCommitContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Check the CI runs on that commit.
+	return r.checkCommit(ctx)
+}
+
+// validate returns an error if the repository is missing its owner or name.
+func (r *Repository) validate() error {
 	if r.Name == "" {
 		return ErrorNoRepositoryName
 	}
 	if r.Owner == "" {
 		return ErrorNoRepositoryOwner
 	}
+	if _, err := r.Checks.matcher(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkCommit gets the CI runs for the commit in the Sha field from each
+// source the repository uses, and checks if they were successful and
+// completed.
+func (r *Repository) checkCommit(ctx context.Context) error {
 
-	// Set the url for checking the most recent commit.
-	// If the user provided a url (like in a test),
-	// then override it.
-	url := r.CommitsURL
-	if len(params) > 0 {
-		url = params[0].commitsURL
+	// Check the individual CI runs.
+	r.RunsResult = CheckRunsAPI{}
+	if r.Sources.usesCheckRuns() {
+		if err := r.CheckRunsContext(ctx); err != nil {
+			return err
+		}
 	}
 
-	// Get the most recent commit.
-	err := r.GetMostRecentCommit(getMostRecentCommitArgs{url})
-	if err != nil {
-		return err
+	// Check the commit statuses.
+	r.StatusResult = CombinedStatusAPI{}
+	if r.Sources.usesStatuses() {
+		if err := r.StatusesContext(ctx); err != nil {
+			return err
+		}
 	}
 
-	// Get url for checking the check-runs API.
-	// Override it (like for tests) if user provided arguments.
-	url = r.RunsURL
-	if len(params) > 0 {
-		url = params[0].runsURL
+	// Check the check suites.
+	r.SuitesResult = CheckSuitesAPI{}
+	if r.UseSuites {
+		if err := r.CheckSuitesContext(ctx); err != nil {
+			return err
+		}
 	}
 
-	// Check the individual CI runs.
-	err = r.CheckRuns(checkRunsArgs{url})
-	if err != nil {
-		return err
+	// Get the checks required by branch protection. If the token can't
+	// read the protection rule, carry on with the rulesets.
+	r.RequiredResult = RequiredChecksAPI{}
+	r.ProtectionErr = nil
+	if r.UseProtection {
+		err := r.RequiredStatusChecksContext(ctx)
+		if errors.Is(err, ErrorProtectionAccess) {
+			r.ProtectionErr = err
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// Check if there are runs from any source.
+	r.HasCheckRuns = len(r.runs()) > 0
+	if !r.HasCheckRuns {
+		r.Success = false
+		r.Completed = true
 	}
 
 	// Check if the CI runs were successful
 	// and Completed.
 	r.RunsAreSuccessful()
 	r.RunsAreComplete()
+	r.RunsHaveFailed()
 
 	// No error.
 	return nil
@@ -1,9 +1,13 @@
 package checkgitci
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Mock date for commits API endpoint.
@@ -110,6 +114,28 @@ var skippedAPIRuns = `{
 		   ]
 		 }`
 
+// newTestServer returns a server that routes check-runs API requests to
+// runsHandler, and every other request to commitsHandler.
+func newTestServer(commitsHandler, runsHandler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/check-runs") {
+			runsHandler(w, r)
+			return
+		}
+		commitsHandler(w, r)
+	}))
+}
+
+// newTestClient returns a Client that sends requests to a test server.
+func newTestClient(t *testing.T, server *httptest.Server, opts ...ClientOption) *Client {
+	t.Helper()
+	client, err := NewClient(append([]ClientOption{WithBaseURL(server.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	return client
+}
+
 type TestResult struct {
 	err          error
 	success      bool
@@ -121,23 +147,23 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 
 	// Setup test cases.
 	testCases := []struct {
-		testName      string
-		repoOwner     string
-		repoName      string
-		commitsServer *httptest.Server
-		runsServer    *httptest.Server
-		expected      TestResult
+		testName       string
+		repoOwner      string
+		repoName       string
+		commitsHandler http.HandlerFunc
+		runsHandler    http.HandlerFunc
+		expected       TestResult
 	}{
 		{
 			testName:  "no repository name",
 			repoOwner: "facebook",
 			repoName:  "",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// This should never be called since name is blank...
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// This should never be called since name is blank...
-			})),
+			},
 			expected: TestResult{
 				err:          ErrorNoRepositoryName,
 				success:      false,
@@ -149,12 +175,12 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "no repository owner",
 			repoOwner: "",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// This should never be called since owner is blank...
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// This should never be called since owner is blank...
-			})),
+			},
 			expected: TestResult{
 				err:          ErrorNoRepositoryOwner,
 				success:      false,
@@ -166,14 +192,14 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "bad response from GitHub commits API",
 			repoOwner: "bad-octocat-owner",
 			repoName:  "bad-octocat-name",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// Send back a bad request.
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write([]byte(`octocat says that is not a real repository owner or name`))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// This server's response should no matter...
-			})),
+			},
 			expected: TestResult{
 				err:          ErrorFailedAPICall,
 				success:      false,
@@ -185,15 +211,15 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "bad response from GitHub check-runs API",
 			repoOwner: "facebook",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockCommitsAPI1))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// Send bad response.
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write([]byte(`octocat says that is a bad request to check-runs api`))
-			})),
+			},
 			expected: TestResult{
 				err:          ErrorFailedAPICall,
 				success:      false,
@@ -205,18 +231,18 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "io.ReadAll error",
 			repoOwner: "facebook",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// This reponse doesn't matter here, only the runsServer.
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockCommitsAPI1))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// Simulate an io.ReadAll error:
 				// https://stackoverflow.com/questions/53171123/how-to-force-error-on-reading-response-body
 				w.Header().Set("Content-Length", "1")
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(`The response here is not of length 1 as specified in the header`))
-			})),
+			},
 			expected: TestResult{
 				err:          ErrorIOReadAll,
 				success:      false,
@@ -228,16 +254,16 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "3 fully successful and complete runs",
 			repoOwner: "facebook",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// The response doesn't matter here as long as the status is ok, because only
 				// the response from the runsServer is important in this test case.
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockCommitsAPI1))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockRunsAPI1))
-			})),
+			},
 			expected: TestResult{
 				err:          nil,
 				success:      true,
@@ -249,16 +275,16 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "3 complete runs, 2 success, 1 skipped",
 			repoOwner: "facebook",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// The response doesn't matter here as long as the status is ok, because only
 				// the response from the runsServer is important in this test case.
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockCommitsAPI1))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockRunsAPI1))
-			})),
+			},
 			expected: TestResult{
 				err:          nil,
 				success:      true,
@@ -270,16 +296,16 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "1 failed run but all runs complete",
 			repoOwner: "facebook",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// The response doesn't matter here as long as the status is ok, because only
 				// the response from the runsServer is important in this test case.
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockCommitsAPI1))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockRunsAPI2))
-			})),
+			},
 			expected: TestResult{
 				err:          nil,
 				success:      false,
@@ -291,16 +317,16 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "1 incomplete run",
 			repoOwner: "facebook",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// The response doesn't matter here as long as the status is ok, because only
 				// the response from the runsServer is important in this test case.
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockCommitsAPI1))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockRunsAPI3))
-			})),
+			},
 			expected: TestResult{
 				err:          nil,
 				success:      false,
@@ -312,16 +338,16 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 			testName:  "no runs",
 			repoOwner: "facebook",
 			repoName:  "react",
-			commitsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
 				// The response doesn't matter here as long as the status is ok, because only
 				// the response from the runsServer is important in this test case.
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockCommitsAPI1))
-			})),
-			runsServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte(mockRunsAPINoRuns))
-			})),
+			},
 			expected: TestResult{
 				err:          nil,
 				success:      false,
@@ -329,26 +355,102 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 				hasCheckRuns: false,
 			},
 		},
+		{
+			testName:  "html response from GitHub check-runs API",
+			repoOwner: "facebook",
+			repoName:  "react",
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(mockCommitsAPI1))
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
+				// A proxy error page with an ok status.
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`<html><body>Unicorn!</body></html>`))
+			},
+			expected: TestResult{
+				err:          ErrorDecodeResponse,
+				success:      false,
+				completed:    false,
+				hasCheckRuns: false,
+			},
+		},
+		{
+			testName:  "truncated response from GitHub check-runs API",
+			repoOwner: "facebook",
+			repoName:  "react",
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(mockCommitsAPI1))
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(mockRunsAPI1[:40]))
+			},
+			expected: TestResult{
+				err:          ErrorDecodeResponse,
+				success:      false,
+				completed:    false,
+				hasCheckRuns: false,
+			},
+		},
+		{
+			testName:  "empty object from GitHub check-runs API",
+			repoOwner: "facebook",
+			repoName:  "react",
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(mockCommitsAPI1))
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
+				// This must not be mistaken for a commit with no runs.
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{}`))
+			},
+			expected: TestResult{
+				err:          ErrorDecodeResponse,
+				success:      false,
+				completed:    false,
+				hasCheckRuns: false,
+			},
+		},
+		{
+			testName:  "null response from GitHub commits API",
+			repoOwner: "facebook",
+			repoName:  "react",
+			commitsHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`null`))
+			},
+			runsHandler: func(w http.ResponseWriter, r *http.Request) {
+				// This should never be called since the commits response is bad...
+			},
+			expected: TestResult{
+				err:          ErrorDecodeResponse,
+				success:      false,
+				completed:    false,
+				hasCheckRuns: false,
+			},
+		},
 	}
 
 	// Iterate over each individual test case (tc).
 	for _, tc := range testCases {
-		// Close servers when we're done.
-		defer tc.commitsServer.Close()
-		defer tc.runsServer.Close()
+		// Close the server when we're done.
+		server := newTestServer(tc.commitsHandler, tc.runsHandler)
+		defer server.Close()
 
-		// Create a repository from the test case.
-		repo := NewRepository(tc.repoOwner, tc.repoName)
+		// Create a repository from the test case, with a client
+		// pointed at the test server.
+		repo := NewRepository(tc.repoOwner, tc.repoName, WithClient(newTestClient(t, server)))
 
 		// Make a call via receiver to the TestMostRecentCommitWasSuccess
-		// function, but override the urls to use test urls.
-		err := repo.MostRecentCommitWasSuccess(mostRecentCommitArgs{
-			commitsURL: tc.commitsServer.URL,
-			runsURL:    tc.runsServer.URL,
-		})
+		// function.
+		err := repo.MostRecentCommitWasSuccess()
 
 		// Check for the expected error.
-		if err != tc.expected.err {
+		if !errors.Is(err, tc.expected.err) {
 			t.Errorf("expected error to be %v but got %v", tc.expected.err, err)
 		}
 
@@ -369,3 +471,85 @@ func TestMostRecentCommitWasSuccess(t *testing.T) {
 
 	}
 }
+
+func TestMostRecentCommitWasSuccessContext(t *testing.T) {
+
+	// The runs server never responds until the request is cancelled,
+	// like a GitHub API that is very slow.
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	defer server.Close()
+
+	// A deadline should stop the request to the check-runs API.
+	repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := repo.MostRecentCommitWasSuccessContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap %v but got %v", context.DeadlineExceeded, err)
+	}
+
+	// A cancelled context should stop before making any request.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	repo = NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+	err = repo.GetMostRecentCommitContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error to wrap %v but got %v", context.Canceled, err)
+	}
+	if repo.Sha != "" {
+		t.Errorf("expected sha to be blank but got %q", repo.Sha)
+	}
+}
+
+// errorMatches reports whether err is expected, treating a nil expected
+// error as requiring no error at all.
+func errorMatches(err, expected error) bool {
+	if expected == nil {
+		return err == nil
+	}
+	return errors.Is(err, expected)
+}
+
+func TestMostRecentCommitWasSuccessForBranch(t *testing.T) {
+
+	// The commits server returns a different head for the release branch.
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("sha") == "release/1.4" {
+			w.Write([]byte(`[{"sha": "release123"}]`))
+			return
+		}
+		w.Write([]byte(mockCommitsAPI1))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if strings.Contains(r.URL.Path, "/release123/") {
+			w.Write([]byte(mockRunsAPI2))
+			return
+		}
+		w.Write([]byte(mockRunsAPI1))
+	})
+	defer server.Close()
+
+	// The default branch passed...
+	repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+	if err := repo.MostRecentCommitWasSuccess(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Sha != "hijklmnop" || !repo.Success {
+		t.Errorf("expected default branch head to pass but got sha %q, success %v", repo.Sha, repo.Success)
+	}
+
+	// ...but the release branch failed.
+	repo = NewRepository("facebook", "react", WithClient(newTestClient(t, server)), ForBranch("release/1.4"))
+	if err := repo.MostRecentCommitWasSuccess(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.Sha != "release123" || repo.Success || !repo.Completed {
+		t.Errorf("expected release branch head to fail but got sha %q, success %v", repo.Sha, repo.Success)
+	}
+}
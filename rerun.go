@@ -0,0 +1,58 @@
+package checkgitci
+
+// WithLatestAttempts makes a repository only count the latest attempt of
+// each run. Re-running a job (for example with "Re-run failed jobs")
+// creates a new check run with the same name, and the check runs API
+// returns both the old and the new attempt. With this option, runs with
+// the same name from the same app are grouped together, and only the
+// most recently started one counts.
+func WithLatestAttempts() RepositoryOption {
+	return func(r *Repository) {
+		r.LatestOnly = true
+	}
+}
+
+// runKey identifies the attempts of one run.
+type runKey struct {
+	origin RunOrigin
+	appID  int64
+	name   string
+}
+
+// latestAttempts returns the latest attempt of each run, in the order the
+// runs were first seen, and the number of earlier attempts left out.
+func latestAttempts(runs []Run) ([]Run, int) {
+	var latest []Run
+	index := map[runKey]int{}
+	superseded := 0
+	for _, run := range runs {
+		key := runKey{origin: run.Origin, appID: run.App.ID, name: run.Name}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(latest)
+			latest = append(latest, run)
+			continue
+		}
+		superseded++
+		if newerAttempt(run, latest[i]) {
+			latest[i] = run
+		}
+	}
+	return latest, superseded
+}
+
+// newerAttempt reports whether run a is a later attempt than run b. Runs
+// are ordered by when they started, and then by ID, since GitHub gives
+// newer runs larger IDs. A run that has not started yet is newer than one
+// that has.
+func newerAttempt(a, b Run) bool {
+	switch {
+	case a.StartedAt.IsZero() && !b.StartedAt.IsZero():
+		return true
+	case !a.StartedAt.IsZero() && b.StartedAt.IsZero():
+		return false
+	case !a.StartedAt.Equal(b.StartedAt):
+		return a.StartedAt.After(b.StartedAt)
+	}
+	return a.ID > b.ID
+}
@@ -0,0 +1,146 @@
+package checkgitci
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Mock data for a job that failed, and then passed when it was re-run.
+var mockRunsAPIRerun = `{
+		   "total_count": 3,
+		   "check_runs": [
+		     {
+		       "id": 12,
+		       "name": "Node.js 14 on mac",
+		       "status": "completed",
+		       "conclusion": "success",
+		       "started_at": "2022-02-14T02:10:00Z",
+		       "completed_at": "2022-02-14T02:14:00Z",
+		       "app": {"id": 15368, "slug": "github-actions", "name": "GitHub Actions"}
+		     },
+		     {
+		       "id": 11,
+		       "name": "Node.js 14 on ubuntu",
+		       "status": "completed",
+		       "conclusion": "success",
+		       "started_at": "2022-02-14T01:38:26Z",
+		       "completed_at": "2022-02-14T01:42:29Z",
+		       "app": {"id": 15368, "slug": "github-actions", "name": "GitHub Actions"}
+		     },
+		     {
+		       "id": 10,
+		       "name": "Node.js 14 on mac",
+		       "status": "completed",
+		       "conclusion": "failure",
+		       "started_at": "2022-02-14T01:38:26Z",
+		       "completed_at": "2022-02-14T01:42:29Z",
+		       "app": {"id": 15368, "slug": "github-actions", "name": "GitHub Actions"}
+		     }
+		   ]
+		 }`
+
+// Mock data for a failed job that is being re-run, and a run with the same
+// name from a different app.
+var mockRunsAPIRerunQueued = `{
+		   "total_count": 3,
+		   "check_runs": [
+		     {
+		       "id": 10,
+		       "name": "build",
+		       "status": "completed",
+		       "conclusion": "failure",
+		       "started_at": "2022-02-14T01:38:26Z",
+		       "completed_at": "2022-02-14T01:42:29Z",
+		       "app": {"id": 15368, "slug": "github-actions", "name": "GitHub Actions"}
+		     },
+		     {
+		       "id": 12,
+		       "name": "build",
+		       "status": "queued",
+		       "conclusion": null,
+		       "started_at": null,
+		       "completed_at": null,
+		       "app": {"id": 15368, "slug": "github-actions", "name": "GitHub Actions"}
+		     },
+		     {
+		       "id": 11,
+		       "name": "build",
+		       "status": "completed",
+		       "conclusion": "success",
+		       "started_at": "2022-02-14T01:38:26Z",
+		       "completed_at": "2022-02-14T01:42:29Z",
+		       "app": {"id": 254, "slug": "circleci-checks", "name": "CircleCI Checks"}
+		     }
+		   ]
+		 }`
+
+func TestLatestAttempts(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName   string
+		runs       string
+		latestOnly bool
+		verdict    Verdict
+		runCount   int
+		superseded int
+	}{
+		{
+			testName: "re-run counts every attempt by default",
+			runs:     mockRunsAPIRerun,
+			verdict:  VerdictFailed,
+			runCount: 3,
+		},
+		{
+			testName:   "re-run that passed",
+			runs:       mockRunsAPIRerun,
+			latestOnly: true,
+			verdict:    VerdictPassed,
+			runCount:   2,
+			superseded: 1,
+		},
+		{
+			testName:   "re-run that has not started",
+			runs:       mockRunsAPIRerunQueued,
+			latestOnly: true,
+			verdict:    VerdictPending,
+			runCount:   2,
+			superseded: 1,
+		},
+		{
+			testName:   "runs with no re-runs",
+			runs:       mockRunsAPI1,
+			latestOnly: true,
+			verdict:    VerdictPassed,
+			runCount:   3,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(tc.runs))
+		})
+		defer server.Close()
+
+		opts := []RepositoryOption{WithClient(newTestClient(t, server))}
+		if tc.latestOnly {
+			opts = append(opts, WithLatestAttempts())
+		}
+		repo := NewRepository("facebook", "react", opts...)
+		eval := repo.EvaluateMostRecentCommit()
+		if eval.Verdict != tc.verdict {
+			t.Errorf("%s: expected verdict %q but got %q (%s)", tc.testName, tc.verdict, eval.Verdict, eval.Reason)
+		}
+		if len(eval.Runs) != tc.runCount || eval.Superseded != tc.superseded {
+			t.Errorf("%s: expected %d runs and %d superseded but got %d and %d", tc.testName, tc.runCount, tc.superseded, len(eval.Runs), eval.Superseded)
+		}
+		if repo.Success != (tc.verdict == VerdictPassed) {
+			t.Errorf("%s: expected success to be %v but got %v", tc.testName, tc.verdict == VerdictPassed, repo.Success)
+		}
+	}
+}
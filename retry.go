@@ -0,0 +1,180 @@
+package checkgitci
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures how requests that fail with a transient error
+// (a 5xx response, or a network error) are retried. Each retry waits for
+// an exponentially growing backoff, randomized by Jitter. Requests
+// rejected by a rate limit are not retried, unless the client waits for
+// rate limits (see WithRateLimitWait).
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the
+	// first. A value of one disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry, and each
+	// later wait is Multiplier times longer, up to MaxBackoff. A
+	// Retry-After header longer than MaxBackoff stops the retries.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Jitter randomizes each wait by up to this fraction of it, in
+	// either direction, so many clients don't retry in lockstep.
+	Jitter float64
+
+	// MaxElapsed stops retrying once a retry would finish waiting
+	// this long after the first attempt started. Zero means no limit.
+	MaxElapsed time.Duration
+
+	// OnRetry, if not nil, is called before waiting for each retry.
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is about to be retried.
+type RetryEvent struct {
+	// URL is the requested url, with any credentials removed.
+	URL string
+
+	// Attempt is the number of the failed attempt, starting at one.
+	Attempt int
+
+	// Err is the error from the failed attempt.
+	Err error
+
+	// Wait is how long the client will wait before the next attempt.
+	Wait time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used by WithRetry for any fields
+// left as zero: three attempts, waiting about half a second and then about
+// a second, with 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetry retries requests that fail with a transient error, according
+// to policy. Zero fields in policy, other than Jitter, MaxElapsed and
+// OnRetry, are set from DefaultRetryPolicy.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) error {
+		defaults := DefaultRetryPolicy()
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = defaults.MaxAttempts
+		}
+		if policy.InitialBackoff <= 0 {
+			policy.InitialBackoff = defaults.InitialBackoff
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = defaults.MaxBackoff
+		}
+		if policy.Multiplier < 1 {
+			policy.Multiplier = defaults.Multiplier
+		}
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			policy.Jitter = defaults.Jitter
+		}
+		c.retry = &policy
+		return nil
+	}
+}
+
+// isRetryable reports whether an error from a single request is transient.
+func isRetryable(ctx context.Context, err error) bool {
+	// Errors caused by the context are final.
+	if ctx.Err() != nil {
+		return false
+	}
+
+	// Retry server errors. Rate limits are left to rateLimitWait, so
+	// they are only waited for when the client asked to.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError && !apiErr.rateLimited
+	}
+
+	// Retry network errors from sending the request, like a reset
+	// connection. Other errors, like a request that can't be built or a
+	// body that can't be read, would happen again.
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(urlErr.Err, &netErr) || errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF)
+}
+
+// retryWait returns how long to wait before retrying a request after the
+// given failed attempt, and whether the request should be retried.
+func (c *Client) retryWait(ctx context.Context, attempt int, start time.Time, err error) (time.Duration, bool) {
+	if c.retry == nil || attempt >= c.retry.MaxAttempts || !isRetryable(ctx, err) {
+		return 0, false
+	}
+
+	// Grow the backoff exponentially, up to the maximum.
+	wait := float64(c.retry.InitialBackoff)
+	for i := 1; i < attempt && wait < float64(c.retry.MaxBackoff); i++ {
+		wait *= c.retry.Multiplier
+	}
+	if wait > float64(c.retry.MaxBackoff) {
+		wait = float64(c.retry.MaxBackoff)
+	}
+
+	// Randomize the wait by up to Jitter in either direction, without
+	// going past the maximum.
+	wait += wait * c.retry.Jitter * (2*jitterRand() - 1)
+	backoff := time.Duration(wait)
+	if backoff > c.retry.MaxBackoff {
+		backoff = c.retry.MaxBackoff
+	}
+
+	// Respect a longer wait requested by GitHub, but give up rather
+	// than wait longer than the policy allows.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RateLimit.RetryAfter > backoff {
+		if apiErr.RateLimit.RetryAfter > c.retry.MaxBackoff {
+			return 0, false
+		}
+		backoff = apiErr.RateLimit.RetryAfter
+	}
+
+	// Don't retry past the elapsed time budget or the context deadline.
+	now := c.now()
+	if c.retry.MaxElapsed > 0 && now.Add(backoff).Sub(start) > c.retry.MaxElapsed {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(backoff)) {
+		return 0, false
+	}
+	return backoff, true
+}
+
+// Random numbers for jitter come from a seeded source, since the global
+// source is not seeded before Go 1.20.
+var (
+	jitterMu     sync.Mutex
+	jitterSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// jitterRand returns a random number in [0, 1).
+func jitterRand() float64 {
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return jitterSource.Float64()
+}
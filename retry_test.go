@@ -0,0 +1,279 @@
+package checkgitci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newFlakyServer returns a server that fails the first failures requests,
+// and then responds with commits. A status of zero closes the connection
+// instead of responding.
+func newFlakyServer(t *testing.T, status, failures int, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if *requests <= failures {
+			if status == 0 {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Fatalf("unable to hijack connection: %v", err)
+				}
+				conn.Close()
+				return
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}))
+}
+
+// useFakeClock makes a client sleep on a fake clock, and returns
+// the waits it makes.
+func useFakeClock(client *Client) *[]time.Duration {
+	now := time.Unix(1700000000, 0)
+	waits := []time.Duration{}
+	client.now = func() time.Time { return now }
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+	return &waits
+}
+
+func TestRetry(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		status   int
+		failures int
+		policy   RetryPolicy
+		requests int
+		waits    []time.Duration
+		err      error
+	}{
+		{
+			testName: "bad gateway twice then success",
+			status:   http.StatusBadGateway,
+			failures: 2,
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, Jitter: 0},
+			requests: 3,
+			waits:    []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			testName: "attempts exhausted",
+			status:   http.StatusServiceUnavailable,
+			failures: 3,
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, Jitter: 0},
+			requests: 3,
+			waits:    []time.Duration{time.Second, 2 * time.Second},
+			err:      ErrorFailedAPICall,
+		},
+		{
+			testName: "secondary rate limit is left to rate limit waiting",
+			status:   http.StatusTooManyRequests,
+			failures: 1,
+			policy:   RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second, Jitter: 0},
+			requests: 1,
+			waits:    []time.Duration{},
+			err:      ErrorFailedAPICall,
+		},
+		{
+			testName: "not found is not retried",
+			status:   http.StatusNotFound,
+			failures: 1,
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, Jitter: 0},
+			requests: 1,
+			waits:    []time.Duration{},
+			err:      ErrorFailedAPICall,
+		},
+		{
+			testName: "connection reset then success",
+			status:   0,
+			failures: 1,
+			policy:   RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second, Jitter: 0},
+			requests: 2,
+			waits:    []time.Duration{time.Second},
+		},
+		{
+			testName: "backoff is capped",
+			status:   http.StatusInternalServerError,
+			failures: 3,
+			policy:   RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second, Jitter: 0},
+			requests: 4,
+			waits:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			testName: "elapsed time budget",
+			status:   http.StatusInternalServerError,
+			failures: 3,
+			policy:   RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxElapsed: 2 * time.Second, Jitter: 0},
+			requests: 2,
+			waits:    []time.Duration{time.Second},
+			err:      ErrorFailedAPICall,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		requests := 0
+		server := newFlakyServer(t, tc.status, tc.failures, &requests)
+		defer server.Close()
+
+		// Record each retry with the hook.
+		retries := 0
+		tc.policy.OnRetry = func(e RetryEvent) {
+			retries++
+			if e.Attempt != retries || e.Err == nil {
+				t.Errorf("%s: unexpected retry event %+v", tc.testName, e)
+			}
+		}
+		client := newTestClient(t, server, WithRetry(tc.policy))
+		waits := useFakeClock(client)
+
+		repo := NewRepository("facebook", "react", WithClient(client))
+		err := repo.GetMostRecentCommit()
+
+		// Check for the expected error.
+		if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Errorf("%s: expected error to be %v but got %v", tc.testName, tc.err, err)
+		}
+
+		// Check the requests and waits.
+		if requests != tc.requests {
+			t.Errorf("%s: expected %d requests but got %d", tc.testName, tc.requests, requests)
+		}
+		if len(*waits) != len(tc.waits) || retries != len(tc.waits) {
+			t.Errorf("%s: expected waits %v but got %v (%d retries)", tc.testName, tc.waits, *waits, retries)
+			continue
+		}
+		for i := range tc.waits {
+			if (*waits)[i] != tc.waits[i] {
+				t.Errorf("%s: expected waits %v but got %v", tc.testName, tc.waits, *waits)
+				break
+			}
+		}
+	}
+}
+
+func TestRetryJitter(t *testing.T) {
+	requests := 0
+	server := newFlakyServer(t, http.StatusBadGateway, 5, &requests)
+	defer server.Close()
+
+	// Waits should stay within the jitter bounds.
+	client := newTestClient(t, server, WithRetry(RetryPolicy{MaxAttempts: 6, InitialBackoff: time.Second, Multiplier: 1, Jitter: 0.5}))
+	waits := useFakeClock(client)
+	repo := NewRepository("facebook", "react", WithClient(client))
+	if err := repo.GetMostRecentCommit(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, wait := range *waits {
+		if wait < 500*time.Millisecond || wait > 1500*time.Millisecond {
+			t.Errorf("expected wait within jitter bounds but got %v", wait)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName   string
+		status     int
+		retryAfter string
+		opts       []ClientOption
+		requests   int
+		waits      []time.Duration
+		err        error
+	}{
+		{
+			testName:   "short Retry-After is respected",
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "5",
+			requests:   2,
+			waits:      []time.Duration{5 * time.Second},
+		},
+		{
+			testName:   "Retry-After past the maximum backoff stops retrying",
+			status:     http.StatusServiceUnavailable,
+			retryAfter: "3600",
+			requests:   1,
+			waits:      []time.Duration{},
+			err:        ErrorFailedAPICall,
+		},
+		{
+			testName:   "rate limit Retry-After past the maximum wait",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "3600",
+			opts:       []ClientOption{WithRateLimitWait(10 * time.Second)},
+			requests:   1,
+			waits:      []time.Duration{},
+			err:        ErrorFailedAPICall,
+		},
+		{
+			testName:   "rate limit Retry-After within the maximum wait",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "5",
+			opts:       []ClientOption{WithRateLimitWait(10 * time.Second)},
+			requests:   2,
+			waits:      []time.Duration{5 * time.Second},
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				w.Header().Set("Retry-After", tc.retryAfter)
+				w.WriteHeader(tc.status)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}))
+		defer server.Close()
+
+		opts := append([]ClientOption{WithRetry(RetryPolicy{InitialBackoff: time.Second, Jitter: 0})}, tc.opts...)
+		client := newTestClient(t, server, opts...)
+		waits := useFakeClock(client)
+		err := NewRepository("facebook", "react", WithClient(client)).GetMostRecentCommit()
+		if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Errorf("%s: expected error to be %v but got %v", tc.testName, tc.err, err)
+		}
+		if requests != tc.requests || !reflect.DeepEqual(*waits, tc.waits) {
+			t.Errorf("%s: expected %d requests and waits %v but got %d and %v", tc.testName, tc.requests, tc.waits, requests, *waits)
+		}
+	}
+}
+
+func TestRetryRequestErrors(t *testing.T) {
+	retries := 0
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second}
+	policy.OnRetry = func(e RetryEvent) {
+		retries++
+	}
+	client, err := NewClient(WithRetry(policy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waits := useFakeClock(client)
+
+	// A request without a URL can't be sent, so it isn't retried.
+	repo := NewRepository("facebook", "react", WithClient(client))
+	if err := repo.CheckRuns(); err == nil {
+		t.Errorf("expected an error for a blank runs URL")
+	}
+	if retries != 0 || len(*waits) != 0 {
+		t.Errorf("expected no retries but got %d and waits %v", retries, *waits)
+	}
+}
@@ -0,0 +1,156 @@
+package checkgitci
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// RunSource selects which GitHub APIs are used to find the CI runs for
+// a commit.
+type RunSource int
+
+const (
+	// CheckRunsOnly uses the check runs API, which GitHub Actions and
+	// other GitHub Apps report to. This is the default.
+	CheckRunsOnly RunSource = iota
+
+	// StatusesOnly uses the commit status API, which older CI services
+	// like Jenkins, CircleCI and Travis CI often report to.
+	StatusesOnly
+
+	// CheckRunsAndStatuses uses both APIs, and a commit only passes if
+	// both its check runs and its statuses pass.
+	CheckRunsAndStatuses
+)
+
+// RunOrigin identifies the GitHub API a Run came from.
+type RunOrigin string
+
+const (
+	// OriginCheckRun is a run from the check runs API.
+	OriginCheckRun RunOrigin = "check_run"
+
+	// OriginStatus is a run converted from a commit status.
+	OriginStatus RunOrigin = "status"
+)
+
+// WithSources sets which GitHub APIs a repository uses to find the CI runs
+// for a commit.
+func WithSources(sources RunSource) RepositoryOption {
+	return func(r *Repository) {
+		r.Sources = sources
+	}
+}
+
+// usesCheckRuns reports whether the check runs API is one of the sources.
+func (s RunSource) usesCheckRuns() bool {
+	return s != StatusesOnly
+}
+
+// usesStatuses reports whether the commit status API is one of the sources.
+func (s RunSource) usesStatuses() bool {
+	return s == StatusesOnly || s == CheckRunsAndStatuses
+}
+
+// Statuses queries the GitHub combined commit status API endpoint for the
+// commit in the Sha field, and attaches select JSON to the Repository
+// struct StatusResult field. Statuses returns an error or nil if no error.
+func (r *Repository) Statuses() error {
+	return r.StatusesContext(context.Background())
+}
+
+// StatusesContext is like Statuses, but stops waiting for the GitHub API
+// when ctx is cancelled or its deadline passes.
+func (r *Repository) StatusesContext(ctx context.Context) error {
+	if r.Sha == "" {
+		return ErrorNoRef
+	}
+	statusURL := addQuery(r.client().statusURL(r.Owner, r.Name, r.Sha), url.Values{"per_page": {strconv.Itoa(perPage)}})
+
+	// Make the requests, following each page of statuses. The
+	// combined state is the same on every page.
+	var result CombinedStatusAPI
+	total, err := r.client().forEachCountedPage(ctx, statusURL, "statuses", func(resp *apiResponse, items json.RawMessage) error {
+		var statuses []CommitStatus
+		if err := resp.decodeItems(items, &statuses); err != nil {
+			return err
+		}
+		var page struct {
+			State string `json:"state"`
+		}
+		if err := resp.decode(&page); err != nil {
+			return err
+		}
+		result.State = page.State
+		result.Statuses = append(result.Statuses, statuses...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	result.TotalCount = total
+	r.StatusResult = result
+	return nil
+}
+
+// statusRun converts a commit status to the shape of a check run. Pending
+// statuses are in progress, and error statuses are failures.
+func statusRun(status CommitStatus) Run {
+	run := Run{
+		Name:      status.Context,
+		StartedAt: status.CreatedAt,
+		Origin:    OriginStatus,
+	}
+	switch status.State {
+	case "pending":
+		run.Status = StatusInProgress
+	case "success":
+		run.Status = StatusCompleted
+		run.Conclusion = ConclusionSuccess
+		run.CompletedAt = status.UpdatedAt
+	default:
+		run.Status = StatusCompleted
+		run.Conclusion = ConclusionFailure
+		run.CompletedAt = status.UpdatedAt
+	}
+	return run
+}
+
+// runs returns the CI runs for the commit from every source the
+// repository uses, with statuses converted to runs. If the repository is
+// scoped to some apps, or only counts the latest attempts, the other runs
+// are left out.
+func (r *Repository) runs() []Run {
+	runs, _ := r.selectRuns()
+	return runs
+}
+
+// selectRuns returns the runs that count for the commit, and the number
+// of earlier attempts of re-run runs that were left out.
+func (r *Repository) selectRuns() ([]Run, int) {
+	runs := r.allRuns()
+	if len(r.Apps) > 0 {
+		runs = appRuns(runs, r.Apps)
+	}
+	if r.LatestOnly {
+		return latestAttempts(runs)
+	}
+	return runs, 0
+}
+
+// allRuns returns the CI runs for the commit from every source the
+// repository uses, including earlier attempts of re-run runs.
+func (r *Repository) allRuns() []Run {
+	var runs []Run
+	if r.Sources.usesCheckRuns() {
+		runs = append(runs, r.RunsResult.CheckRuns...)
+	}
+	if r.Sources.usesStatuses() {
+		for _, status := range r.StatusResult.Statuses {
+			runs = append(runs, statusRun(status))
+		}
+	}
+	return runs
+}
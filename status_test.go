@@ -0,0 +1,117 @@
+package checkgitci
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Mock data for the combined status API endpoint.
+var mockStatusAPISuccess = `{
+		   "state": "success",
+		   "total_count": 2,
+		   "statuses": [
+		     {"context": "ci/jenkins", "state": "success", "created_at": "2022-02-14T01:38:26Z", "updated_at": "2022-02-14T01:42:29Z"},
+		     {"context": "ci/circleci", "state": "success", "created_at": "2022-02-14T01:38:26Z", "updated_at": "2022-02-14T01:42:29Z"}
+		   ]
+		 }`
+
+var mockStatusAPIFailure = `{
+		   "state": "failure",
+		   "total_count": 2,
+		   "statuses": [
+		     {"context": "ci/jenkins", "state": "success", "created_at": "2022-02-14T01:38:26Z", "updated_at": "2022-02-14T01:42:29Z"},
+		     {"context": "ci/circleci", "state": "error", "created_at": "2022-02-14T01:38:26Z", "updated_at": "2022-02-14T01:42:29Z"}
+		   ]
+		 }`
+
+var mockStatusAPIPending = `{
+		   "state": "pending",
+		   "total_count": 1,
+		   "statuses": [
+		     {"context": "ci/jenkins", "state": "pending", "created_at": "2022-02-14T01:38:26Z", "updated_at": "2022-02-14T01:38:26Z"}
+		   ]
+		 }`
+
+func TestStatuses(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		sources  RunSource
+		runs     string
+		statuses string
+		expected TestResult
+	}{
+		{
+			testName: "statuses are ignored by default",
+			sources:  CheckRunsOnly,
+			runs:     mockRunsAPINoRuns,
+			statuses: mockStatusAPISuccess,
+			expected: TestResult{success: false, completed: true, hasCheckRuns: false},
+		},
+		{
+			testName: "passing statuses only",
+			sources:  StatusesOnly,
+			runs:     mockRunsAPI2,
+			statuses: mockStatusAPISuccess,
+			expected: TestResult{success: true, completed: true, hasCheckRuns: true},
+		},
+		{
+			testName: "pending statuses only",
+			sources:  StatusesOnly,
+			runs:     mockRunsAPI1,
+			statuses: mockStatusAPIPending,
+			expected: TestResult{success: false, completed: false, hasCheckRuns: true},
+		},
+		{
+			testName: "passing runs and passing statuses",
+			sources:  CheckRunsAndStatuses,
+			runs:     mockRunsAPI1,
+			statuses: mockStatusAPISuccess,
+			expected: TestResult{success: true, completed: true, hasCheckRuns: true},
+		},
+		{
+			testName: "passing runs and failing statuses",
+			sources:  CheckRunsAndStatuses,
+			runs:     mockRunsAPI1,
+			statuses: mockStatusAPIFailure,
+			expected: TestResult{success: false, completed: true, hasCheckRuns: true},
+		},
+		{
+			testName: "no runs and no statuses",
+			sources:  CheckRunsAndStatuses,
+			runs:     mockRunsAPINoRuns,
+			statuses: `{"state": "pending", "total_count": 0, "statuses": []}`,
+			expected: TestResult{success: false, completed: true, hasCheckRuns: false},
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if strings.HasSuffix(r.URL.Path, "/status") {
+				w.Write([]byte(tc.statuses))
+				return
+			}
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(tc.runs))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), WithSources(tc.sources))
+		if err := repo.MostRecentCommitWasSuccess(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.testName, err)
+		}
+
+		// Check for the expected states.
+		if repo.Success != tc.expected.success || repo.Completed != tc.expected.completed || repo.HasCheckRuns != tc.expected.hasCheckRuns {
+			t.Errorf("%s: expected success %v, completed %v, has runs %v but got %v, %v, %v", tc.testName,
+				tc.expected.success, tc.expected.completed, tc.expected.hasCheckRuns,
+				repo.Success, repo.Completed, repo.HasCheckRuns)
+		}
+	}
+}
@@ -4,15 +4,32 @@ import "time"
 
 // Repository type holds information for individual Git repositories.
 type Repository struct {
-	Owner        string
-	Name         string
-	Sha          string
-	RunsResult   CheckRunsAPI
-	HasCheckRuns bool
-	Success      bool
-	Completed    bool
-	CommitsURL   string
-	RunsURL      string
+	Owner          string
+	Name           string
+	Branch         string
+	PullRequest    *PullRequestAPI
+	HeadChanged    bool
+	Sha            string
+	RunsResult     CheckRunsAPI
+	StatusResult   CombinedStatusAPI
+	SuitesResult   CheckSuitesAPI
+	Sources        RunSource
+	UseSuites      bool
+	Conclusions    ConclusionPolicy
+	Checks         *ChecksPolicy
+	UseProtection  bool
+	RequiredResult RequiredChecksAPI
+	ProtectionErr  error
+	LatestOnly     bool
+	RunsFilter     RunsFilter
+	Apps           []string
+	HasCheckRuns   bool
+	Success        bool
+	Completed      bool
+	Failed         bool
+	CommitsURL     string
+	RunsURL        string
+	Client         *Client
 }
 
 // CommitsAPI holds selected information on the response from GitHub commits API.
@@ -20,6 +37,43 @@ type CommitsAPI []struct {
 	Sha string
 }
 
+// CommitAPI holds selected information on the response from the GitHub
+// commits API for a single commit.
+type CommitAPI struct {
+	Sha string `json:"sha"`
+}
+
+// PullRequestAPI holds selected information from the GitHub pulls API.
+type PullRequestAPI struct {
+	Number int    `json:"number"`
+	State  string `json:"state"`
+
+	// Mergeable is nil while GitHub is still computing whether the
+	// pull request can be merged.
+	Mergeable      *bool  `json:"mergeable"`
+	MergeableState string `json:"mergeable_state"`
+
+	Head PullRequestBranch `json:"head"`
+	Base PullRequestBranch `json:"base"`
+}
+
+// PullRequestBranch holds selected information on the head or base branch
+// of a pull request.
+type PullRequestBranch struct {
+	Ref string `json:"ref"`
+	Sha string `json:"sha"`
+
+	// Repo is nil if the repository has been deleted, such as a fork
+	// deleted after its pull request was opened.
+	Repo *PullRequestRepo `json:"repo"`
+}
+
+// PullRequestRepo holds selected information on the repository of a pull
+// request branch.
+type PullRequestRepo struct {
+	FullName string `json:"full_name"`
+}
+
 // CheckRunsAPI holds selected information from the GitHub check-runs API.
 type CheckRunsAPI struct {
 	TotalCount int   `json:"total_count"`
@@ -28,31 +82,86 @@ type CheckRunsAPI struct {
 
 // Run holds selected information on an individual GitHub CI workflow run.
 type Run struct {
-	Name        string    `json:"name"`
-	Status      string    `json:"status"`
-	Conclusion  string    `json:"conclusion"`
-	StartedAt   time.Time `json:"started_at"`
-	CompletedAt time.Time `json:"completed_at"`
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Status      Status     `json:"status"`
+	Conclusion  Conclusion `json:"conclusion"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt time.Time  `json:"completed_at"`
+	App         App        `json:"app"`
+
+	// Origin is the GitHub API the run came from.
+	Origin RunOrigin `json:"-"`
 }
 
-// getMostRecentCommitArgs overrides the url used in
-// the GetMostRecentCommit function. This argument
-// should usually only be supplied for testing.
-type getMostRecentCommitArgs struct {
-	url string
+// CheckSuitesAPI holds selected information from the GitHub check-suites API.
+type CheckSuitesAPI struct {
+	TotalCount  int     `json:"total_count"`
+	CheckSuites []Suite `json:"check_suites"`
 }
 
-// checkRunsArgs overrides the url used in
-// the CheckRuns function. This argument should
-// usually only be supplied for testing.
-type checkRunsArgs struct {
-	url string
+// Suite holds selected information on a GitHub check suite, which groups
+// the check runs created by one GitHub App for a commit.
+type Suite struct {
+	ID            int64      `json:"id"`
+	Status        Status     `json:"status"`
+	Conclusion    Conclusion `json:"conclusion"`
+	HeadBranch    string     `json:"head_branch"`
+	HeadSha       string     `json:"head_sha"`
+	Rerequestable bool       `json:"rerequestable"`
+	App           App        `json:"app"`
+
+	// LatestCheckRunsCount is the number of check runs in the suite.
+	LatestCheckRunsCount int `json:"latest_check_runs_count"`
+}
+
+// App holds selected information on the GitHub App that produced a check
+// suite or check run.
+type App struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// CombinedStatusAPI holds selected information from the GitHub combined
+// commit status API.
+type CombinedStatusAPI struct {
+	State      string         `json:"state"`
+	TotalCount int            `json:"total_count"`
+	Statuses   []CommitStatus `json:"statuses"`
+}
+
+// CommitStatus holds selected information on the latest status reported
+// for one context, such as "ci/jenkins" or "continuous-integration/travis-ci".
+type CommitStatus struct {
+	Context     string    `json:"context"`
+	State       string    `json:"state"`
+	Description string    `json:"description"`
+	TargetURL   string    `json:"target_url"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RequiredChecksAPI holds the required status checks for a branch, from
+// its branch protection rule and the repository's rulesets.
+type RequiredChecksAPI struct {
+	// Branch is the protected branch the checks are required on.
+	Branch string
+
+	// Strict is true if branches must be up to date before merging.
+	Strict bool
+
+	// Checks lists the required checks.
+	Checks []RequiredCheck
 }
 
-// mostRecentCommitArgs overrides the urls used in
-// the MostRecentCommitWasSuccess function. This argument
-// should usually only be supplied for testing.
-type mostRecentCommitArgs struct {
-	commitsURL string
-	runsURL    string
+// RequiredCheck is a check that must pass before merging into a protected
+// branch.
+type RequiredCheck struct {
+	// Context is the name of the check run or commit status.
+	Context string `json:"context"`
+
+	// AppID is the GitHub App the check must come from, or zero or -1
+	// if it can come from any source.
+	AppID int64 `json:"app_id"`
 }
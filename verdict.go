@@ -0,0 +1,230 @@
+package checkgitci
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Verdict is the overall CI result for a commit.
+type Verdict int
+
+const (
+	// VerdictUnknown means the commit has not been evaluated.
+	VerdictUnknown Verdict = iota
+
+	// VerdictPassed means every run that counts has completed, and
+	// all of them passed.
+	VerdictPassed
+
+	// VerdictFailed means every run that counts has completed, and
+	// at least one of them failed.
+	VerdictFailed
+
+	// VerdictPending means some runs that count have not completed,
+	// or a check suite has not created its runs yet.
+	VerdictPending
+
+	// VerdictNoChecks means the commit has no runs that count, such
+	// as in a repository without CI.
+	VerdictNoChecks
+
+	// VerdictError means the CI result could not be found, because
+	// of an error.
+	VerdictError
+)
+
+// String returns the name of the verdict.
+func (v Verdict) String() string {
+	switch v {
+	case VerdictPassed:
+		return "passed"
+	case VerdictFailed:
+		return "failed"
+	case VerdictPending:
+		return "pending"
+	case VerdictNoChecks:
+		return "no checks"
+	case VerdictError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Evaluation is the overall CI result for a commit, with a breakdown of
+// how each run contributed to it.
+type Evaluation struct {
+	// Verdict is the overall result.
+	Verdict Verdict
+
+	// Sha is the commit that was evaluated.
+	Sha string
+
+	// Reason explains why the verdict was reached.
+	Reason string
+
+	// Runs holds the outcome of each run.
+	Runs []RunResult
+
+	// Missing lists the required checks with no runs, when the
+	// repository has a ChecksPolicy.
+	Missing []string
+
+	// DefinitelyFailed is true if the commit can no longer pass, because
+	// a run that counts has already failed, or a missing required check
+	// counts as a failure. It can be true while the verdict is still
+	// VerdictPending, so callers can stop waiting early.
+	DefinitelyFailed bool
+
+	// Superseded is the number of earlier attempts of re-run runs that
+	// were left out, when the repository only counts the latest
+	// attempts.
+	Superseded int
+
+	// Err is the error that stopped the evaluation, when the verdict
+	// is VerdictError.
+	Err error
+}
+
+// RunResult is the outcome of one run in an Evaluation.
+type RunResult struct {
+	Run     Run
+	Outcome Outcome
+
+	// Reason explains why the run has its outcome.
+	Reason string
+}
+
+// runResults returns the outcome of each of the repository's runs, and
+// the required checks that have no runs.
+func (r *Repository) runResults() ([]RunResult, []string, error) {
+	checks, err := r.checksMatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	policy := r.conclusionPolicy()
+	runs := r.runs()
+	var results []RunResult
+	for _, run := range runs {
+		result := RunResult{Run: run}
+		if counted, reason := checks.counts(run); !counted {
+			result.Outcome = OutcomeIgnore
+			result.Reason = reason
+		} else if !run.Status.IsCompleted() {
+			result.Outcome = OutcomePending
+			result.Reason = fmt.Sprintf("status is %q", run.Status)
+		} else {
+			result.Outcome = policy.Outcome(run.Conclusion)
+			result.Reason = fmt.Sprintf("conclusion %q counts as %s", run.Conclusion, result.Outcome)
+		}
+		results = append(results, result)
+	}
+	return results, checks.missingChecks(runs), nil
+}
+
+// missingIsPending reports whether missing required checks mean the runs
+// are not complete, rather than that the commit failed.
+func (r *Repository) missingIsPending() bool {
+	return r.Checks == nil || r.Checks.Missing == MissingPending
+}
+
+// Evaluate returns the overall CI result for the commit in the Sha field,
+// from the runs already fetched (for example by MostRecentCommitWasSuccess,
+// CheckRef or CheckPullRequest). It does not make any requests.
+func (r *Repository) Evaluate() Evaluation {
+	eval := Evaluation{Sha: r.Sha}
+	if r.Sha == "" {
+		eval.Reason = "no commit has been checked"
+		return eval
+	}
+	results, missing, err := r.runResults()
+	if err != nil {
+		eval.Verdict = VerdictError
+		eval.Reason = err.Error()
+		eval.Err = err
+		return eval
+	}
+	eval.Runs = results
+	eval.Missing = missing
+	_, eval.Superseded = r.selectRuns()
+
+	// Count the outcomes.
+	counts := map[Outcome]int{}
+	for _, result := range eval.Runs {
+		counts[result.Outcome]++
+	}
+	eval.DefinitelyFailed = counts[OutcomeFail] > 0 || (len(missing) > 0 && !r.missingIsPending())
+
+	// Pending runs mean the result could still change, so they come
+	// first. Then any failure fails the commit.
+	switch {
+	case r.hasWaitingSuites():
+		eval.Verdict = VerdictPending
+		eval.Reason = "a check suite has not created its runs yet"
+	case counts[OutcomePending] > 0:
+		eval.Verdict = VerdictPending
+		eval.Reason = fmt.Sprintf("%d of %d runs have not completed", counts[OutcomePending], len(eval.Runs))
+	case len(missing) > 0 && r.missingIsPending():
+		eval.Verdict = VerdictPending
+		eval.Reason = fmt.Sprintf("required checks have no runs yet: %s", strings.Join(missing, ", "))
+	case counts[OutcomeFail] > 0:
+		eval.Verdict = VerdictFailed
+		eval.Reason = fmt.Sprintf("%d of %d runs failed", counts[OutcomeFail], len(eval.Runs))
+	case len(missing) > 0:
+		eval.Verdict = VerdictFailed
+		eval.Reason = fmt.Sprintf("required checks have no runs: %s", strings.Join(missing, ", "))
+	case counts[OutcomePass] == 0:
+		eval.Verdict = VerdictNoChecks
+		eval.Reason = fmt.Sprintf("no runs count towards the result (%d ignored)", counts[OutcomeIgnore])
+	default:
+		eval.Verdict = VerdictPassed
+		eval.Reason = fmt.Sprintf("%d of %d runs passed (%d ignored)", counts[OutcomePass], len(eval.Runs), counts[OutcomeIgnore])
+	}
+	return eval
+}
+
+// EvaluateMostRecentCommit gets the most recent commit and its CI runs
+// like MostRecentCommitWasSuccess, and returns the overall result. If the
+// runs cannot be fetched, the verdict is VerdictError, and the error is
+// in the Err field.
+func (r *Repository) EvaluateMostRecentCommit() Evaluation {
+	return r.EvaluateMostRecentCommitContext(context.Background())
+}
+
+// EvaluateMostRecentCommitContext is like EvaluateMostRecentCommit, but
+// stops waiting for the GitHub API when ctx is cancelled or its deadline
+// passes.
+func (r *Repository) EvaluateMostRecentCommitContext(ctx context.Context) Evaluation {
+	if err := r.MostRecentCommitWasSuccessContext(ctx); err != nil {
+		return errorEvaluation(r.Sha, err)
+	}
+	return r.Evaluate()
+}
+
+// errorEvaluation returns the evaluation of a commit whose runs could not
+// be fetched.
+func errorEvaluation(sha string, err error) Evaluation {
+	return Evaluation{Verdict: VerdictError, Sha: sha, Reason: err.Error(), Err: err}
+}
+
+// failFast returns the evaluation as failed if it is pending, but the
+// commit has definitely failed. Otherwise it returns the evaluation
+// unchanged.
+func (eval Evaluation) failFast() Evaluation {
+	if eval.Verdict != VerdictPending || !eval.DefinitelyFailed {
+		return eval
+	}
+	failed := 0
+	for _, result := range eval.Runs {
+		if result.Outcome == OutcomeFail {
+			failed++
+		}
+	}
+	eval.Verdict = VerdictFailed
+	if failed > 0 {
+		eval.Reason = fmt.Sprintf("%d of %d runs failed while others are pending", failed, len(eval.Runs))
+	} else {
+		eval.Reason = fmt.Sprintf("required checks have no runs: %s", strings.Join(eval.Missing, ", "))
+	}
+	return eval
+}
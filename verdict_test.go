@@ -0,0 +1,190 @@
+package checkgitci
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestEvaluateMostRecentCommit(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		policy   ConclusionPolicy
+		runs     string
+		status   int
+		verdict  Verdict
+		outcomes []Outcome
+	}{
+		{
+			testName: "all runs passed",
+			runs:     mockRunsAPI1,
+			status:   http.StatusOK,
+			verdict:  VerdictPassed,
+			outcomes: []Outcome{OutcomePass, OutcomePass, OutcomePass},
+		},
+		{
+			testName: "one run failed",
+			runs:     mockRunsAPI2,
+			status:   http.StatusOK,
+			verdict:  VerdictFailed,
+			outcomes: []Outcome{OutcomePass, OutcomePass, OutcomeFail},
+		},
+		{
+			testName: "one run pending",
+			runs:     mockRunsAPI3,
+			status:   http.StatusOK,
+			verdict:  VerdictPending,
+			outcomes: []Outcome{OutcomePending},
+		},
+		{
+			testName: "no runs",
+			runs:     mockRunsAPINoRuns,
+			status:   http.StatusOK,
+			verdict:  VerdictNoChecks,
+		},
+		{
+			testName: "every run ignored",
+			policy:   ConclusionPolicy{ConclusionSuccess: OutcomeIgnore},
+			runs:     mockRunsAPI1,
+			status:   http.StatusOK,
+			verdict:  VerdictNoChecks,
+			outcomes: []Outcome{OutcomeIgnore, OutcomeIgnore, OutcomeIgnore},
+		},
+		{
+			testName: "runs API error",
+			runs:     `{"message": "Server Error"}`,
+			status:   http.StatusInternalServerError,
+			verdict:  VerdictError,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			w.Write([]byte(tc.runs))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)), WithConclusionPolicy(tc.policy))
+		eval := repo.EvaluateMostRecentCommit()
+		if eval.Verdict != tc.verdict {
+			t.Errorf("%s: expected verdict %q but got %q (%s)", tc.testName, tc.verdict, eval.Verdict, eval.Reason)
+		}
+		if eval.Sha != "hijklmnop" {
+			t.Errorf("%s: expected sha %q but got %q", tc.testName, "hijklmnop", eval.Sha)
+		}
+		if tc.verdict == VerdictError {
+			if !errors.Is(eval.Err, ErrorFailedAPICall) {
+				t.Errorf("%s: expected a failed API call error but got %v", tc.testName, eval.Err)
+			}
+			continue
+		}
+		if len(eval.Runs) != len(tc.outcomes) {
+			t.Fatalf("%s: expected %d runs but got %d", tc.testName, len(tc.outcomes), len(eval.Runs))
+		}
+		for i, result := range eval.Runs {
+			if result.Outcome != tc.outcomes[i] || result.Reason == "" {
+				t.Errorf("%s: run %d: expected outcome %q but got %q (%s)", tc.testName, i, tc.outcomes[i], result.Outcome, result.Reason)
+			}
+		}
+	}
+}
+
+func TestEvaluatePendingTakesPrecedence(t *testing.T) {
+	repo := &Repository{
+		Sha: "abc123",
+		RunsResult: CheckRunsAPI{TotalCount: 2, CheckRuns: []Run{
+			{Name: "lint", Status: StatusCompleted, Conclusion: ConclusionFailure},
+			{Name: "test", Status: StatusInProgress},
+		}},
+	}
+	if eval := repo.Evaluate(); eval.Verdict != VerdictPending {
+		t.Errorf("expected verdict %q but got %q", VerdictPending, eval.Verdict)
+	}
+
+	// Without a commit, there is nothing to evaluate.
+	if eval := (&Repository{}).Evaluate(); eval.Verdict != VerdictUnknown {
+		t.Errorf("expected verdict %q but got %q", VerdictUnknown, eval.Verdict)
+	}
+}
+
+func TestDefinitelyFailed(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName  string
+		runs      string
+		opts      []RepositoryOption
+		verdict   Verdict
+		failed    bool
+		completed bool
+	}{
+		{
+			testName: "failed run while another is pending",
+			runs:     mockRunsAPIFailedPending,
+			verdict:  VerdictPending,
+			failed:   true,
+		},
+		{
+			testName: "pending runs",
+			runs:     mockRunsAPI3,
+			verdict:  VerdictPending,
+		},
+		{
+			testName:  "failed runs that are complete",
+			runs:      mockRunsAPI2,
+			verdict:   VerdictFailed,
+			failed:    true,
+			completed: true,
+		},
+		{
+			testName:  "passed runs",
+			runs:      mockRunsAPI1,
+			verdict:   VerdictPassed,
+			completed: true,
+		},
+		{
+			testName: "failed run that is not required",
+			runs:     mockRunsAPIFailedPending,
+			opts:     []RepositoryOption{WithChecksPolicy(ChecksPolicy{Required: []string{"test"}})},
+			verdict:  VerdictPending,
+		},
+		{
+			testName: "missing required check that counts as failure",
+			runs:     mockRunsAPI3,
+			opts:     []RepositoryOption{WithChecksPolicy(ChecksPolicy{Required: []string{"Node.js *", "deploy"}, Missing: MissingFail})},
+			verdict:  VerdictPending,
+			failed:   true,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(tc.runs))
+		})
+		defer server.Close()
+
+		repo := NewRepository("facebook", "react", append([]RepositoryOption{WithClient(newTestClient(t, server))}, tc.opts...)...)
+		eval := repo.EvaluateMostRecentCommit()
+		if eval.Verdict != tc.verdict || eval.DefinitelyFailed != tc.failed {
+			t.Errorf("%s: expected verdict %q and definitely failed %v but got %q and %v (%s)", tc.testName, tc.verdict, tc.failed, eval.Verdict, eval.DefinitelyFailed, eval.Reason)
+		}
+		if repo.Failed != tc.failed || repo.Completed != tc.completed {
+			t.Errorf("%s: expected failed %v and completed %v but got %v and %v", tc.testName, tc.failed, tc.completed, repo.Failed, repo.Completed)
+		}
+		if failed := eval.failFast(); (failed.Verdict == VerdictFailed) != (tc.failed || tc.verdict == VerdictFailed) {
+			t.Errorf("%s: unexpected fail fast verdict %q", tc.testName, failed.Verdict)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package checkgitci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaitOptions configures WaitForCompletion.
+type WaitOptions struct {
+	// Sha is the commit to wait for. If it is empty, the most recent
+	// commit (see GetMostRecentCommit) is found when the wait starts,
+	// and later pushes don't change it.
+	Sha string
+
+	// FollowHead finds the most recent commit again before each poll,
+	// so the wait moves on to new pushes.
+	FollowHead bool
+
+	// Interval is the wait before the second poll, and each later wait
+	// is Multiplier times longer, up to MaxInterval.
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+
+	// Timeout stops waiting this long after the wait started. Zero
+	// means no limit, other than the context's deadline.
+	Timeout time.Duration
+
+	// FailFast stops waiting as soon as the commit has definitely
+	// failed (see Evaluation.DefinitelyFailed), even if other runs are
+	// still pending.
+	FailFast bool
+
+	// NoChecksGrace keeps waiting while a commit has no checks, for up
+	// to this long after the commit was first polled, since GitHub
+	// creates check suites and runs shortly after a push. Zero ends the
+	// wait on the first poll that finds no checks.
+	NoChecksGrace time.Duration
+}
+
+// DefaultWaitOptions returns the options used by WaitForCompletion for any
+// interval fields left as zero: polls ten seconds apart, slowing down to a
+// minute apart.
+func DefaultWaitOptions() WaitOptions {
+	return WaitOptions{
+		Interval:    10 * time.Second,
+		MaxInterval: time.Minute,
+		Multiplier:  1.5,
+	}
+}
+
+// WaitTimeoutError is returned when WaitForCompletion times out before the
+// runs complete. It satisfies errors.Is(err, ErrorWaitTimeout).
+type WaitTimeoutError struct {
+	// Last is the evaluation from the last poll.
+	Last Evaluation
+
+	// Elapsed is how long the wait took.
+	Elapsed time.Duration
+
+	// Err is the context error, if the context's deadline stopped the
+	// wait.
+	Err error
+}
+
+// Error returns the error message.
+func (e *WaitTimeoutError) Error() string {
+	return fmt.Sprintf("%s after %s: commit %s is %s: %s", ErrorWaitTimeout.Error(), e.Elapsed, e.Last.Sha, e.Last.Verdict, e.Last.Reason)
+}
+
+// Is reports whether target is ErrorWaitTimeout.
+func (e *WaitTimeoutError) Is(target error) bool {
+	return target == ErrorWaitTimeout
+}
+
+// Unwrap returns the context error, if there is one.
+func (e *WaitTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForCompletion polls the CI runs for a commit until they complete,
+// and returns the final evaluation. Polls slow down according to opts, and
+// wait for the rate limit to reset if no requests remain. If the wait
+// times out, the error is a *WaitTimeoutError holding the last evaluation.
+// Other errors stop the wait, and are returned with an evaluation whose
+// verdict is VerdictError. A commit with no checks ends the wait with
+// VerdictNoChecks, unless opts.NoChecksGrace is set, so a wait started
+// right after a push should set it.
+func (r *Repository) WaitForCompletion(ctx context.Context, opts WaitOptions) (Evaluation, error) {
+	inGrace := r.noChecksGrace(opts.NoChecksGrace)
+	return r.pollUntil(ctx, opts, func(eval Evaluation) (Evaluation, bool) {
+		if eval.Verdict != VerdictPending && !inGrace(eval) {
+			return eval, true
+		}
+		if opts.FailFast {
+			if failed := eval.failFast(); failed.Verdict == VerdictFailed {
+				return failed, true
+			}
+		}
+		return eval, false
+	})
+}
+
+// noChecksGrace returns a function that reports whether an evaluation
+// with no checks is for a commit first seen less than grace ago, so the
+// wait should carry on.
+func (r *Repository) noChecksGrace(grace time.Duration) func(Evaluation) bool {
+	c := r.client()
+	var sha string
+	var since time.Time
+	return func(eval Evaluation) bool {
+		if eval.Sha != sha {
+			sha, since = eval.Sha, c.now()
+		}
+		return eval.Verdict == VerdictNoChecks && c.now().Sub(since) < grace
+	}
+}
+
+// pollUntil polls the CI runs for a commit, as set by opts, and calls done
+// with each evaluation until done reports that polling is over, returning
+// the evaluation from done. The interval between polls starts again when
+// the commit changes.
+func (r *Repository) pollUntil(ctx context.Context, opts WaitOptions, done func(Evaluation) (Evaluation, bool)) (Evaluation, error) {
+	opts = opts.withDefaults()
+	c := r.client()
+	start := c.now()
+	if err := r.validate(); err != nil {
+		return errorEvaluation(r.Sha, err), err
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// Pin the commit to wait for.
+	sha := opts.Sha
+	if sha == "" && !opts.FollowHead {
+		if err := r.GetMostRecentCommitContext(ctx); err != nil {
+			return r.waitError(start, Evaluation{}, err)
+		}
+		sha = r.Sha
+	}
+
+	interval := opts.Interval
+	last := Evaluation{Sha: sha}
+	for {
+		// Check the runs on the commit.
+		eval, err := r.poll(ctx, sha, opts.FollowHead)
+		if err != nil {
+			return r.waitError(start, last, err)
+		}
+		if eval.Sha != last.Sha {
+			interval = opts.Interval
+		}
+		last = eval
+		if result, ok := done(eval); ok {
+			return result, nil
+		}
+
+		// Wait for the next poll, unless that would be past the
+		// timeout.
+		wait := r.pollWait(interval)
+		if opts.Timeout > 0 && c.now().Add(wait).Sub(start) > opts.Timeout {
+			return eval, &WaitTimeoutError{Last: eval, Elapsed: c.now().Sub(start)}
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return r.waitError(start, eval, err)
+		}
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+// withDefaults returns the options with zero interval fields set from
+// DefaultWaitOptions.
+func (opts WaitOptions) withDefaults() WaitOptions {
+	defaults := DefaultWaitOptions()
+	if opts.Interval <= 0 {
+		opts.Interval = defaults.Interval
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = defaults.MaxInterval
+	}
+	if opts.MaxInterval < opts.Interval {
+		opts.MaxInterval = opts.Interval
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = defaults.Multiplier
+	}
+	return opts
+}
+
+// poll checks the runs on the commit sha, or on the most recent commit if
+// followHead is true, and evaluates them.
+func (r *Repository) poll(ctx context.Context, sha string, followHead bool) (Evaluation, error) {
+	if followHead {
+		if err := r.GetMostRecentCommitContext(ctx); err != nil {
+			return errorEvaluation(r.Sha, err), err
+		}
+	} else {
+		r.Sha = sha
+		r.setRunsURL()
+	}
+	if err := r.checkCommit(ctx); err != nil {
+		return errorEvaluation(r.Sha, err), err
+	}
+	return r.Evaluate(), nil
+}
+
+// pollWait returns how long to wait before the next poll. If no requests
+// remain in the current rate limit window, the wait lasts until it resets.
+func (r *Repository) pollWait(interval time.Duration) time.Duration {
+	c := r.client()
+	rl := c.RateLimit()
+	if rl.Limit > 0 && rl.Remaining == 0 && !rl.Reset.IsZero() {
+		if untilReset := rl.Reset.Sub(c.now()) + rateLimitResetSlack; untilReset > interval {
+			return untilReset
+		}
+	}
+	return interval
+}
+
+// waitError returns the result of a wait stopped by err. A context
+// deadline is a timeout, with the evaluation from the last poll.
+func (r *Repository) waitError(start time.Time, last Evaluation, err error) (Evaluation, error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return last, &WaitTimeoutError{Last: last, Elapsed: r.client().now().Sub(start), Err: err}
+	}
+	return errorEvaluation(r.Sha, err), err
+}
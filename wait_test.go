@@ -0,0 +1,213 @@
+package checkgitci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Mock data for a run that has already failed while another is pending.
+var mockRunsAPIFailedPending = `{
+		   "total_count": 2,
+		   "check_runs": [
+		     {"name": "lint", "status": "completed", "conclusion": "failure"},
+		     {"name": "test", "status": "in_progress", "conclusion": null}
+		   ]
+		 }`
+
+func TestWaitForCompletion(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName  string
+		opts      WaitOptions
+		runs      []string
+		rateLimit bool
+		verdict   Verdict
+		waits     []time.Duration
+		err       error
+	}{
+		{
+			testName: "pending until passed",
+			runs:     []string{mockRunsAPI3, mockRunsAPI3, mockRunsAPI1},
+			verdict:  VerdictPassed,
+			waits:    []time.Duration{10 * time.Second, 15 * time.Second},
+		},
+		{
+			testName: "pending until failed",
+			opts:     WaitOptions{Interval: time.Second, Multiplier: 2, MaxInterval: 3 * time.Second},
+			runs:     []string{mockRunsAPI3, mockRunsAPI3, mockRunsAPI3, mockRunsAPI2},
+			verdict:  VerdictFailed,
+			waits:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			testName: "timeout",
+			opts:     WaitOptions{Timeout: 30 * time.Second},
+			runs:     []string{mockRunsAPI3},
+			verdict:  VerdictPending,
+			waits:    []time.Duration{10 * time.Second, 15 * time.Second},
+			err:      ErrorWaitTimeout,
+		},
+		{
+			testName: "waits for a failure to finish without fail fast",
+			runs:     []string{mockRunsAPIFailedPending, mockRunsAPI2},
+			verdict:  VerdictFailed,
+			waits:    []time.Duration{10 * time.Second},
+		},
+		{
+			testName: "fail fast",
+			opts:     WaitOptions{FailFast: true},
+			runs:     []string{mockRunsAPIFailedPending},
+			verdict:  VerdictFailed,
+			waits:    []time.Duration{},
+		},
+		{
+			testName: "no checks ends the wait",
+			runs:     []string{mockRunsAPINoRuns, mockRunsAPI1},
+			verdict:  VerdictNoChecks,
+			waits:    []time.Duration{},
+		},
+		{
+			testName: "no checks during the grace period",
+			opts:     WaitOptions{NoChecksGrace: time.Minute},
+			runs:     []string{mockRunsAPINoRuns, mockRunsAPINoRuns, mockRunsAPI3, mockRunsAPI1},
+			verdict:  VerdictPassed,
+			waits:    []time.Duration{10 * time.Second, 15 * time.Second, 22500 * time.Millisecond},
+		},
+		{
+			testName: "no checks after the grace period",
+			opts:     WaitOptions{NoChecksGrace: 20 * time.Second},
+			runs:     []string{mockRunsAPINoRuns, mockRunsAPINoRuns, mockRunsAPINoRuns, mockRunsAPI1},
+			verdict:  VerdictNoChecks,
+			waits:    []time.Duration{10 * time.Second, 15 * time.Second},
+		},
+		{
+			testName:  "waits for the rate limit to reset",
+			runs:      []string{mockRunsAPI3, mockRunsAPI1},
+			rateLimit: true,
+			verdict:   VerdictPassed,
+			waits:     []time.Duration{2*time.Minute + time.Second},
+		},
+		{
+			testName: "runs API error",
+			runs:     []string{mockRunsAPI3, `{"message": "Not Found"}`},
+			verdict:  VerdictError,
+			waits:    []time.Duration{10 * time.Second},
+			err:      ErrorFailedAPICall,
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		var client *Client
+		polls := 0
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			runs := tc.runs[len(tc.runs)-1]
+			if polls < len(tc.runs) {
+				runs = tc.runs[polls]
+			}
+			polls++
+			if tc.rateLimit {
+				w.Header().Set("X-RateLimit-Limit", "5000")
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(client.now().Add(2*time.Minute).Unix(), 10))
+			}
+			if strings.Contains(runs, "Not Found") {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+			w.Write([]byte(runs))
+		})
+		defer server.Close()
+
+		client = newTestClient(t, server)
+		waits := useFakeClock(client)
+		repo := NewRepository("facebook", "react", WithClient(client))
+		eval, err := repo.WaitForCompletion(context.Background(), tc.opts)
+
+		// Check for the expected error.
+		if !errors.Is(err, tc.err) || (tc.err == nil && err != nil) {
+			t.Errorf("%s: expected error to be %v but got %v", tc.testName, tc.err, err)
+		}
+		var timeoutErr *WaitTimeoutError
+		if errors.As(err, &timeoutErr) && timeoutErr.Last.Verdict != VerdictPending {
+			t.Errorf("%s: expected the timeout to hold the last evaluation but got %+v", tc.testName, timeoutErr.Last)
+		}
+
+		// Check the verdict and waits.
+		if eval.Verdict != tc.verdict || eval.Sha != "hijklmnop" {
+			t.Errorf("%s: expected verdict %q for hijklmnop but got %q for %q (%s)", tc.testName, tc.verdict, eval.Verdict, eval.Sha, eval.Reason)
+		}
+		if !reflect.DeepEqual(*waits, tc.waits) {
+			t.Errorf("%s: expected waits %v but got %v", tc.testName, tc.waits, *waits)
+		}
+	}
+}
+
+func TestWaitForCompletionPinsCommit(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName   string
+		followHead bool
+		sha        string
+	}{
+		{
+			testName: "pinned to the first head",
+			sha:      "hijklmnop",
+		},
+		{
+			testName:   "follows new pushes",
+			followHead: true,
+			sha:        "newhead",
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+
+		// A new commit is pushed after the first poll, and its runs
+		// pass, but the runs on the first head never finish.
+		heads := 0
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if heads == 0 {
+				w.Write([]byte(mockCommitsAPI1))
+			} else {
+				w.Write([]byte(`[{"sha": "newhead"}]`))
+			}
+			heads++
+		}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if strings.Contains(r.URL.Path, "/newhead/") {
+				w.Write([]byte(mockRunsAPI1))
+				return
+			}
+			w.Write([]byte(mockRunsAPI3))
+		})
+		defer server.Close()
+
+		client := newTestClient(t, server)
+		useFakeClock(client)
+		repo := NewRepository("facebook", "react", WithClient(client))
+		eval, err := repo.WaitForCompletion(context.Background(), WaitOptions{FollowHead: tc.followHead, Timeout: time.Minute})
+		if eval.Sha != tc.sha {
+			t.Errorf("%s: expected sha %q but got %q", tc.testName, tc.sha, eval.Sha)
+		}
+		if tc.followHead && (err != nil || eval.Verdict != VerdictPassed) {
+			t.Errorf("%s: expected the new head to pass but got %q and %v", tc.testName, eval.Verdict, err)
+		}
+		if !tc.followHead && !errors.Is(err, ErrorWaitTimeout) {
+			t.Errorf("%s: expected a timeout but got %v", tc.testName, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package checkgitci
+
+import (
+	"context"
+	"errors"
+)
+
+// EventType is the kind of change reported by Watch.
+type EventType int
+
+const (
+	// EventRunQueued means a run was created, but has not started.
+	EventRunQueued EventType = iota
+
+	// EventRunStarted means a run started.
+	EventRunStarted
+
+	// EventRunCompleted means a run completed, with a conclusion.
+	EventRunCompleted
+
+	// EventNewCommit means a new commit was pushed, and is now being
+	// watched.
+	EventNewCommit
+
+	// EventVerdictChanged means the overall verdict changed.
+	EventVerdictChanged
+
+	// EventError means the watch stopped because of an error.
+	EventError
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventRunQueued:
+		return "run queued"
+	case EventRunStarted:
+		return "run started"
+	case EventRunCompleted:
+		return "run completed"
+	case EventNewCommit:
+		return "new commit"
+	case EventVerdictChanged:
+		return "verdict changed"
+	case EventError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Event is a change in the CI runs for a commit, reported by Watch.
+type Event struct {
+	Type EventType
+
+	// Sha is the commit the event is about.
+	Sha string
+
+	// Run is the run that changed, for run events.
+	Run RunResult
+
+	// Evaluation is the evaluation after the change. For verdict
+	// changes, Previous is the verdict before it.
+	Evaluation Evaluation
+	Previous   Verdict
+
+	// Err is the error that stopped the watch, for error events.
+	Err error
+}
+
+// Watch polls the CI runs for a commit like WaitForCompletion, and sends
+// an event on the returned channel for each change it sees between polls:
+// runs being queued, starting, and completing, new commits, and changes in
+// the overall verdict. The first poll reports the runs that already exist.
+//
+// The channel is closed when the watch ends. Without FollowHead, it ends
+// after the runs complete (or fail, with FailFast). With FollowHead, it
+// keeps watching new commits until ctx is done. If ctx is cancelled, the
+// channel is closed without an event. Any other error, including a
+// timeout or ctx's deadline passing, is always sent as an EventError
+// before the channel is closed, so it is always the last event. The watch
+// never waits for the caller to receive it: a caller that stops receiving
+// once the buffer is full can miss events, but the channel is still
+// closed. The caller must not use the repository for other calls until the
+// channel is closed.
+func (r *Repository) Watch(ctx context.Context, opts WaitOptions) <-chan Event {
+	events := make(chan Event, 16)
+	go func() {
+		defer close(events)
+		start := r.client().now()
+
+		// send sends an event, and reports whether the watch should
+		// carry on.
+		send := func(event Event) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var w watchState
+		stopped := false
+		inGrace := r.noChecksGrace(opts.NoChecksGrace)
+		last, err := r.pollUntil(ctx, opts, func(eval Evaluation) (Evaluation, bool) {
+			for _, event := range w.diff(eval) {
+				if !send(event) {
+					stopped = true
+					return eval, true
+				}
+			}
+			if opts.FollowHead {
+				return eval, false
+			}
+			if eval.Verdict != VerdictPending && !inGrace(eval) {
+				return eval, true
+			}
+			if opts.FailFast {
+				if failed := eval.failFast(); failed.Verdict == VerdictFailed {
+					if !send(Event{Type: EventVerdictChanged, Sha: eval.Sha, Evaluation: failed, Previous: eval.Verdict}) {
+						stopped = true
+					}
+					return failed, true
+				}
+			}
+			return eval, false
+		})
+
+		// An event that could not be sent because ctx's deadline
+		// passed is a timeout too.
+		if stopped && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = &WaitTimeoutError{Last: last, Elapsed: r.client().now().Sub(start), Err: ctx.Err()}
+		}
+
+		// The final error is sent even though ctx may be done, so a
+		// deadline never closes the channel silently. It never blocks:
+		// if the caller stopped receiving and the buffer is full, the
+		// oldest event is dropped to make room, since this goroutine is
+		// the only sender.
+		if err != nil && !errors.Is(err, context.Canceled) {
+			event := Event{Type: EventError, Sha: r.Sha, Err: err}
+			select {
+			case events <- event:
+			default:
+				select {
+				case <-events:
+				default:
+				}
+				events <- event
+			}
+		}
+	}()
+	return events
+}
+
+// watchKey identifies a run between polls.
+type watchKey struct {
+	runKey
+	id int64
+}
+
+// watchState is the state of a commit's runs at the last poll.
+type watchState struct {
+	sha     string
+	verdict Verdict
+	runs    map[watchKey]Status
+}
+
+// diff returns the events for the changes between the last poll and eval,
+// and records eval as the last poll.
+func (w *watchState) diff(eval Evaluation) []Event {
+	var events []Event
+
+	// A new commit starts with no runs.
+	if eval.Sha != w.sha {
+		if w.sha != "" {
+			events = append(events, Event{Type: EventNewCommit, Sha: eval.Sha, Evaluation: eval})
+		}
+		w.sha = eval.Sha
+		w.runs = map[watchKey]Status{}
+	}
+
+	// Compare each run with its status at the last poll.
+	for _, result := range eval.Runs {
+		run := result.Run
+		key := watchKey{runKey: runKey{origin: run.Origin, appID: run.App.ID, name: run.Name}, id: run.ID}
+		previous, seen := w.runs[key]
+		w.runs[key] = run.Status
+		var eventType EventType
+		switch {
+		case seen && previous == run.Status:
+			continue
+		case run.Status.IsCompleted():
+			eventType = EventRunCompleted
+		case run.Status == StatusInProgress:
+			eventType = EventRunStarted
+		case !seen:
+			eventType = EventRunQueued
+		default:
+			continue
+		}
+		events = append(events, Event{Type: eventType, Sha: eval.Sha, Run: result, Evaluation: eval})
+	}
+
+	// Report a change in the overall verdict.
+	if eval.Verdict != w.verdict {
+		events = append(events, Event{Type: EventVerdictChanged, Sha: eval.Sha, Evaluation: eval, Previous: w.verdict})
+		w.verdict = eval.Verdict
+	}
+	return events
+}
@@ -0,0 +1,207 @@
+package checkgitci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// mockRunsAPIStatus returns check-runs API JSON with one run with the
+// given status and conclusion.
+func mockRunsAPIStatus(status, conclusion string) string {
+	return `{"total_count": 1, "check_runs": [{"id": 1, "name": "test", "status": "` + status + `", "conclusion": ` + conclusion + `}]}`
+}
+
+func TestWatch(t *testing.T) {
+
+	// Setup test cases.
+	testCases := []struct {
+		testName string
+		runs     []string
+		status   int
+		events   []EventType
+		verdicts []Verdict
+	}{
+		{
+			testName: "run queued, started and completed",
+			runs: []string{
+				mockRunsAPIStatus("queued", "null"),
+				mockRunsAPIStatus("queued", "null"),
+				mockRunsAPIStatus("in_progress", "null"),
+				mockRunsAPIStatus("completed", `"success"`),
+			},
+			status:   http.StatusOK,
+			events:   []EventType{EventRunQueued, EventVerdictChanged, EventRunStarted, EventRunCompleted, EventVerdictChanged},
+			verdicts: []Verdict{VerdictPending, VerdictPassed},
+		},
+		{
+			testName: "runs already complete",
+			runs:     []string{mockRunsAPI2},
+			status:   http.StatusOK,
+			events:   []EventType{EventRunCompleted, EventRunCompleted, EventRunCompleted, EventVerdictChanged},
+			verdicts: []Verdict{VerdictFailed},
+		},
+		{
+			testName: "runs API error",
+			runs:     []string{`{"message": "Server Error"}`},
+			status:   http.StatusInternalServerError,
+			events:   []EventType{EventError},
+		},
+	}
+
+	// Iterate over each individual test case (tc).
+	for _, tc := range testCases {
+		polls := 0
+		server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(mockCommitsAPI1))
+		}, func(w http.ResponseWriter, r *http.Request) {
+			runs := tc.runs[len(tc.runs)-1]
+			if polls < len(tc.runs) {
+				runs = tc.runs[polls]
+			}
+			polls++
+			w.WriteHeader(tc.status)
+			w.Write([]byte(runs))
+		})
+		defer server.Close()
+
+		client := newTestClient(t, server)
+		useFakeClock(client)
+		repo := NewRepository("facebook", "react", WithClient(client))
+
+		// Collect events until the channel is closed.
+		var events []EventType
+		var verdicts []Verdict
+		for event := range repo.Watch(context.Background(), WaitOptions{}) {
+			events = append(events, event.Type)
+			if event.Type == EventVerdictChanged {
+				verdicts = append(verdicts, event.Evaluation.Verdict)
+			}
+			if event.Type == EventError && !errors.Is(event.Err, ErrorFailedAPICall) {
+				t.Errorf("%s: unexpected error: %v", tc.testName, event.Err)
+			}
+		}
+		if !reflect.DeepEqual(events, tc.events) {
+			t.Errorf("%s: expected events %v but got %v", tc.testName, tc.events, events)
+		}
+		if !reflect.DeepEqual(verdicts, tc.verdicts) {
+			t.Errorf("%s: expected verdicts %v but got %v", tc.testName, tc.verdicts, verdicts)
+		}
+	}
+}
+
+func TestWatchNewCommit(t *testing.T) {
+
+	// A new commit is pushed after the first poll.
+	heads := 0
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if heads == 0 {
+			w.Write([]byte(mockCommitsAPI1))
+		} else {
+			w.Write([]byte(`[{"sha": "newhead"}]`))
+		}
+		heads++
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockRunsAPI1))
+	})
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	useFakeClock(client)
+	repo := NewRepository("facebook", "react", WithClient(client))
+
+	// Following the head keeps watching after the runs complete, until
+	// the context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var newCommit *Event
+	for event := range repo.Watch(ctx, WaitOptions{FollowHead: true}) {
+		if event.Type == EventError {
+			t.Errorf("unexpected error event: %v", event.Err)
+		}
+		if event.Type == EventNewCommit {
+			event := event
+			newCommit = &event
+			cancel()
+		}
+	}
+	if newCommit == nil || newCommit.Sha != "newhead" {
+		t.Errorf("expected a new commit event for newhead but got %+v", newCommit)
+	}
+}
+
+func TestWatchDeadline(t *testing.T) {
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockRunsAPI3))
+	})
+	defer server.Close()
+
+	// The caller's deadline always ends the watch with a timeout
+	// event, whether it passes during a poll, a wait or a send.
+	for i := 0; i < 20; i++ {
+		repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		var last Event
+		for event := range repo.Watch(ctx, WaitOptions{Interval: 5 * time.Millisecond}) {
+			last = event
+		}
+		cancel()
+		if last.Type != EventError || !errors.Is(last.Err, ErrorWaitTimeout) {
+			t.Fatalf("attempt %d: expected a timeout event last but got %v: %v", i, last.Type, last.Err)
+		}
+	}
+}
+
+func TestWatchStoppedReceiving(t *testing.T) {
+	polls := 0
+	server := newTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(mockCommitsAPI1))
+	}, func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		w.WriteHeader(http.StatusOK)
+		if polls%2 == 0 {
+			w.Write([]byte(mockRunsAPI1))
+		} else {
+			w.Write([]byte(mockRunsAPI3))
+		}
+	})
+	defer server.Close()
+
+	// The runs change on every poll, so events fill the buffer while
+	// nothing receives them.
+	repo := NewRepository("facebook", "react", WithClient(newTestClient(t, server)))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	events := repo.Watch(ctx, WaitOptions{FollowHead: true, Interval: time.Millisecond, MaxInterval: time.Millisecond})
+	time.Sleep(250 * time.Millisecond)
+
+	// The watch should have ended by itself, with the timeout as the
+	// last event.
+	var last Event
+	for {
+		select {
+		case event, ok := <-events:
+			if ok {
+				last = event
+				continue
+			}
+		default:
+			t.Fatalf("expected the watch to have closed the channel")
+		}
+		break
+	}
+	if last.Type != EventError || !errors.Is(last.Err, ErrorWaitTimeout) {
+		t.Errorf("expected a timeout event last but got %v: %v", last.Type, last.Err)
+	}
+}